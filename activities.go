@@ -1,36 +1,258 @@
 package iplocate
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// Application error types for ip-api failures that retrying can't fix. They
+// are listed in DefaultRetryPolicy's NonRetryableErrorTypes.
+const (
+	ErrReservedRange = "ReservedRange"
+	ErrInvalidQuery  = "InvalidQuery"
 )
 
 type HTTPGetter interface {
 	Get(url string) (*http.Response, error)
 }
 
+// httpDoer is implemented by *http.Client. When HTTPClient has it, requests
+// carry the context and the X-Correlation-ID header.
+type httpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+const CorrelationHeader = "X-Correlation-ID"
+
 type IPActivities struct {
 	HTTPClient HTTPGetter
-	mu         sync.Mutex
-	cache      map[string]string
+	Publisher  Publisher
+	// Providers are the GeoProviders ProviderLookup can use, by name.
+	Providers map[string]GeoProvider
+	// ASNames maps AS numbers to organization names for LookupASN. See
+	// LoadASNames.
+	ASNames map[int]string
+	// MaxConcurrent caps in-flight provider requests across all activities
+	// on this worker, since ip-api limits by source IP rather than by
+	// activity slot. Zero means no cap. Set it before the first request.
+	MaxConcurrent int
+	// TimeSeries receives every successful monitor check through
+	// WriteTimeSeries.
+	TimeSeries TimeSeriesSink
+	// AuditLog receives one JSON line per EmitAudit call.
+	AuditLog io.Writer
+	auditMu  sync.Mutex
+	mu       sync.Mutex
+	cache    map[string]string
+	// results holds RecordResult's JSON-encoded Data, and when it was
+	// stored, by IP.
+	results map[string]cachedEntry
+	latency latencyRing
+	semOnce sync.Once
+	sem     chan struct{}
+}
+
+func (i *IPActivities) get(ctx context.Context, url string) (*http.Response, error) {
+	return i.send(ctx, http.MethodGet, url, nil)
+}
+
+// send makes a request through HTTPClient, holding a MaxConcurrent slot
+// until the response body is closed. Methods other than GET need an
+// HTTPClient that implements Do. The activity heartbeats until then too.
+func (i *IPActivities) send(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	stop := heartbeat(ctx)
+	release, err := i.acquire(ctx)
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	done := func() {
+		release()
+		stop()
+	}
+
+	start := time.Now()
+	resp, err := i.do(ctx, method, url, body)
+	i.latency.record(time.Since(start))
+	if err != nil {
+		done()
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		resp.Body.Close()
+		done()
+		return nil, lookupError(CategoryRateLimit, "HTTP "+method, fmt.Errorf("%s: %s", url, resp.Status))
+	}
+	// Hold the slot until the caller has finished reading the body.
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: done}
+	return resp, nil
+}
+
+// heartbeat records heartbeats for the activity running in ctx until stop
+// is called. A cancelled workflow's cancellation only reaches an activity
+// through a heartbeat, so this is what lets it interrupt a request that
+// carries ctx. It does nothing outside an activity or when the activity
+// has no HeartbeatTimeout.
+func heartbeat(ctx context.Context) (stop func()) {
+	if !activity.IsActivity(ctx) || activity.GetInfo(ctx).HeartbeatTimeout <= 0 {
+		return func() {}
+	}
+	ticker := time.NewTicker(activity.GetInfo(ctx).HeartbeatTimeout / 2)
+	done := make(chan struct{})
+	go func() {
+		defer ticker.Stop()
+		for {
+			activity.RecordHeartbeat(ctx)
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func (i *IPActivities) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	doer, ok := i.HTTPClient.(httpDoer)
+	if !ok {
+		if method != http.MethodGet {
+			return nil, fmt.Errorf("HTTPClient %T can't send %s requests", i.HTTPClient, method)
+		}
+		return i.HTTPClient.Get(url)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if id := correlationID(ctx); id != "" {
+		req.Header.Set(CorrelationHeader, id)
+	}
+	return doer.Do(req)
+}
+
+// acquire waits for a MaxConcurrent slot, giving up if ctx is done first.
+func (i *IPActivities) acquire(ctx context.Context) (release func(), err error) {
+	if i.MaxConcurrent <= 0 {
+		return func() {}, nil
+	}
+	i.semOnce.Do(func() {
+		i.sem = make(chan struct{}, i.MaxConcurrent)
+	})
+
+	select {
+	case i.sem <- struct{}{}:
+		var once sync.Once
+		return func() { once.Do(func() { <-i.sem }) }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// correlationID is "<workflow ID>/<run ID>" of the workflow that scheduled
+// the activity, or empty when ctx is not an activity context.
+func correlationID(ctx context.Context) string {
+	if !activity.IsActivity(ctx) {
+		return ""
+	}
+	info := activity.GetInfo(ctx)
+	return info.WorkflowExecution.ID + "/" + info.WorkflowExecution.RunID
+}
+
+type getterFunc func(url string) (*http.Response, error)
+
+func (f getterFunc) Get(url string) (*http.Response, error) {
+	return f(url)
+}
+
+// contextGetter is an HTTPGetter that can also carry a request's context.
+type contextGetter interface {
+	GetContext(ctx context.Context, url string) (*http.Response, error)
+}
+
+// getContext GETs url through getter with ctx attached: through
+// GetContext when getter has it, as a request carrying ctx when it has Do,
+// and as a plain Get otherwise. Providers use it for every request.
+func getContext(ctx context.Context, getter HTTPGetter, url string) (*http.Response, error) {
+	switch g := getter.(type) {
+	case contextGetter:
+		return g.GetContext(ctx, url)
+	case httpDoer:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		return g.Do(req)
+	}
+	return getter.Get(url)
+}
+
+// SharedGetter sends requests the way a's own activities do: through
+// a.HTTPClient, holding a MaxConcurrent slot, recording latency and
+// carrying the activity's context and correlation header. Build the
+// providers in a.Providers on it so ProviderLookup shares those limits.
+// This is a function rather than a method because every exported method of
+// IPActivities is registered as an activity.
+func SharedGetter(a *IPActivities) HTTPGetter {
+	return activityGetter{a}
+}
+
+type activityGetter struct {
+	a *IPActivities
+}
+
+func (g activityGetter) Get(url string) (*http.Response, error) {
+	return g.a.get(context.Background(), url)
+}
+
+func (g activityGetter) GetContext(ctx context.Context, url string) (*http.Response, error) {
+	return g.a.get(ctx, url)
 }
 
 func (i *IPActivities) GetIP(ctx context.Context) (string, error) {
-	resp, err := i.HTTPClient.Get("https://api.ipify.org")
+	return PublicIP(getterFunc(func(url string) (*http.Response, error) {
+		return i.get(ctx, url)
+	}))
+}
+
+// PublicIP returns the egress IP that getter's requests leave from. It is
+// GetIP without the activity wrapper, for use outside workflows.
+func PublicIP(getter HTTPGetter) (string, error) {
+	resp, err := getter.Get("https://api.ipify.org")
 	if err != nil {
-		return "", err
+		return "", lookupError(CategoryNetwork, "HTTP GET", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(resp.Body)
 	if err != nil {
-		return "", err
+		return "", lookupError(CategoryNetwork, "read body", err)
 	}
 
 	return strings.TrimSpace(string(body)), nil
@@ -38,21 +260,18 @@ func (i *IPActivities) GetIP(ctx context.Context) (string, error) {
 
 func (i *IPActivities) GetLocationInfo(ctx context.Context, ip string) (string, error) {
 	url := "http://ip-api.com/json/" + ip
-	fmt.Printf("DEBUG: Fetching location for IP [%s] from URL: %s\n", ip, url)
 
-	resp, err := i.HTTPClient.Get(url)
+	resp, err := i.get(ctx, url)
 	if err != nil {
-		return "", fmt.Errorf("HTTP GET error: %w", err)
+		return "", lookupError(CategoryNetwork, "HTTP GET", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("read body error: %w", err)
+		return "", lookupError(CategoryNetwork, "read body", err)
 	}
 
-	fmt.Printf("DEBUG: Response body: %s\n", string(body))
-
 	var data struct {
 		Status  string `json:"status"`
 		Message string `json:"message"`
@@ -62,30 +281,28 @@ func (i *IPActivities) GetLocationInfo(ctx context.Context, ip string) (string,
 	}
 
 	if err := json.Unmarshal(body, &data); err != nil {
-		return "", fmt.Errorf("JSON unmarshal error: %w", err)
+		return "", lookupError(CategoryParse, "JSON unmarshal", err)
 	}
 
 	if data.Status == "fail" {
-		return "", fmt.Errorf("API error: %s", data.Message)
+		return "", apiError(data.Message)
 	}
 
-	fmt.Printf("DEBUG: Parsed data - City: %s, Region: %s, Country: %s\n", data.City, data.Region, data.Country)
-
 	return fmt.Sprintf("City: %s, Region: %s, Country: %s", data.City, data.Region, data.Country), nil
 }
 
 func (i *IPActivities) GetTimeZone(ctx context.Context, ip string) (string, error) {
 	url := "http://ip-api.com/json/" + ip + "?fields=timezone"
 
-	resp, err := i.HTTPClient.Get(url)
+	resp, err := i.get(ctx, url)
 	if err != nil {
-		return "", fmt.Errorf("HTTP GET error: %w", err)
+		return "", lookupError(CategoryNetwork, "HTTP GET", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("read body error: %w", err)
+		return "", lookupError(CategoryNetwork, "read body", err)
 	}
 
 	var data struct {
@@ -95,26 +312,38 @@ func (i *IPActivities) GetTimeZone(ctx context.Context, ip string) (string, erro
 	}
 
 	if err := json.Unmarshal(body, &data); err != nil {
-		return "", fmt.Errorf("JSON unmarshal error: %w", err)
+		return "", lookupError(CategoryParse, "JSON unmarshal", err)
 	}
 
 	if data.Status == "fail" {
-		return "", fmt.Errorf("API error: %s", data.Message)
+		return "", apiError(data.Message)
 	}
 
 	return data.Timezone, nil
 }
 
-func (i *IPActivities) RecordLookup(ctx context.Context, ip string) (string, error) {
-	recordId := fmt.Sprintf("%d-%s", time.Now().Unix(), ip)
+// RecordLookup caches ip under a new record ID and returns the ID. A
+// non-empty idempotencyKey, such as the workflow run ID, becomes the record
+// ID, so a retried activity gets back the record its first attempt made.
+// Lookups scheduled before the key existed arrive without one and get a
+// timestamp ID.
+func (i *IPActivities) RecordLookup(ctx context.Context, ip, idempotencyKey string) (string, error) {
+	recordId := idempotencyKey
+	if recordId == "" {
+		recordId = fmt.Sprintf("%d-%s", time.Now().Unix(), ip)
+	}
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
 	if i.cache == nil {
 		i.cache = make(map[string]string)
 	}
+	if _, ok := i.cache[recordId]; ok && idempotencyKey != "" {
+		activity.GetLogger(ctx).Debug("Lookup already recorded", "record", recordId, "ip", ip)
+		return recordId, nil
+	}
 	i.cache[recordId] = ip
-	fmt.Printf("Recorded lookup: %s -> %s\n", recordId, ip)
+	activity.GetLogger(ctx).Debug("Recorded lookup", "record", recordId, "ip", ip)
 
 	return recordId, nil
 
@@ -125,7 +354,54 @@ func (i *IPActivities) CompensateLookup(ctx context.Context, recordId string) er
 	defer i.mu.Unlock()
 	if _, ok := i.cache[recordId]; ok {
 		delete(i.cache, recordId)
-		fmt.Printf("Compensated lookup, removed record: %s\n", recordId)
+		activity.GetLogger(ctx).Debug("Compensated lookup, removed record", "record", recordId)
 	}
 	return nil
 }
+
+// RecordResult caches the result of a successful lookup of ip, replacing
+// any earlier one, so later lookups of the same IP can be answered from it.
+func (i *IPActivities) RecordResult(ctx context.Context, ip string, result Data) error {
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.results == nil {
+		i.results = make(map[string]cachedEntry)
+	}
+	i.results[ip] = cachedEntry{encoded: string(encoded), storedAt: time.Now()}
+	activity.GetLogger(ctx).Debug("Recorded result", "ip", ip)
+	return nil
+}
+
+// cachedResult returns the result RecordResult cached for ip.
+func (i *IPActivities) cachedResult(ip string) (Data, bool) {
+	i.mu.Lock()
+	entry, ok := i.results[ip]
+	i.mu.Unlock()
+	if !ok {
+		return Data{}, false
+	}
+	var result Data
+	if err := json.Unmarshal([]byte(entry.encoded), &result); err != nil {
+		return Data{}, false
+	}
+	return result, true
+}
+
+func apiError(message string) error {
+	le := &LookupError{Category: CategoryProviderFail, Op: "API", Err: errors.New(message)}
+	// ip-api answers in lower case, ipwho.is capitalised.
+	switch strings.ToLower(message) {
+	case "private range", "reserved range":
+		le.Category = CategoryValidation
+		return newLookupError(le, ErrReservedRange)
+	case "invalid query", "invalid ip address":
+		le.Category = CategoryValidation
+		return newLookupError(le, ErrInvalidQuery)
+	}
+	return newLookupError(le, string(le.Category))
+}
@@ -2,9 +2,17 @@ package iplocate
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
 )
 
 func TestIPActivities_GetTimeZone(t *testing.T) {
@@ -28,3 +36,182 @@ func TestIPActivities_GetTimeZone(t *testing.T) {
 
 	t.Logf("IP: %s, TimeZone: %s", ip, tz)
 }
+
+// stubGetter serves canned bodies keyed by URL and counts requests.
+type stubGetter struct {
+	mu     sync.Mutex
+	bodies map[string]string
+	calls  map[string]int
+}
+
+func (s *stubGetter) Get(url string) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.calls == nil {
+		s.calls = make(map[string]int)
+	}
+	s.calls[url]++
+
+	body, ok := s.bodies[url]
+	if !ok {
+		return nil, fmt.Errorf("unexpected request to %s", url)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func (s *stubGetter) count(url string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[url]
+}
+
+func TestIPActivities_GetLocationInfo_ReservedRange(t *testing.T) {
+	a := &IPActivities{HTTPClient: &stubGetter{bodies: map[string]string{
+		"http://ip-api.com/json/10.0.0.1": `{"status":"fail","message":"private range","query":"10.0.0.1"}`,
+	}}}
+
+	_, err := a.GetLocationInfo(context.Background(), "10.0.0.1")
+
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || appErr.Type() != ErrReservedRange {
+		t.Fatalf("err = %v, want ApplicationError of type %s", err, ErrReservedRange)
+	}
+}
+
+// recordingDoer answers every request with body and keeps the last request.
+type recordingDoer struct {
+	body string
+	req  *http.Request
+}
+
+func (r *recordingDoer) Get(url string) (*http.Response, error) {
+	return nil, errors.New("Get called instead of Do")
+}
+
+func (r *recordingDoer) Do(req *http.Request) (*http.Response, error) {
+	r.req = req
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(r.body)),
+	}, nil
+}
+
+func TestIPActivities_SetsCorrelationHeader(t *testing.T) {
+	doer := &recordingDoer{body: `{"status":"success","timezone":"Australia/Sydney"}`}
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	a := &IPActivities{HTTPClient: doer}
+	env.RegisterActivity(a)
+
+	if _, err := env.ExecuteActivity(a.GetTimeZone, "1.1.1.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := doer.req.Header.Get(CorrelationHeader)
+	if want := "default-test-workflow-id/default-test-run-id"; got != want {
+		t.Errorf("%s = %q, want %q", CorrelationHeader, got, want)
+	}
+}
+
+// slowGetter tracks how many responses are open at once.
+type slowGetter struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (s *slowGetter) Get(url string) (*http.Response, error) {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.peak {
+		s.peak = s.inFlight
+	}
+	s.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body: &closeHook{
+			Reader: strings.NewReader(`{"status":"success","timezone":"UTC"}`),
+			onClose: func() {
+				s.mu.Lock()
+				s.inFlight--
+				s.mu.Unlock()
+			},
+		},
+	}, nil
+}
+
+type closeHook struct {
+	io.Reader
+	onClose func()
+}
+
+func (c *closeHook) Close() error {
+	c.onClose()
+	return nil
+}
+
+func TestIPActivities_MaxConcurrent(t *testing.T) {
+	getter := &slowGetter{}
+	a := &IPActivities{HTTPClient: getter, MaxConcurrent: 2}
+
+	var wg sync.WaitGroup
+	for n := 0; n < 10; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := a.GetTimeZone(context.Background(), "1.1.1.1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if getter.peak > 2 {
+		t.Errorf("peak concurrent requests = %d, want at most 2", getter.peak)
+	}
+}
+
+func TestIPActivities_MaxConcurrentRespectsContext(t *testing.T) {
+	a := &IPActivities{HTTPClient: &slowGetter{}, MaxConcurrent: 1}
+	release, err := a.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := a.GetTimeZone(ctx, "1.1.1.1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestIPActivities_RecordLookupIdempotent(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestActivityEnvironment()
+	a := &IPActivities{}
+	env.RegisterActivity(a)
+
+	var ids [2]string
+	for k := range ids {
+		val, err := env.ExecuteActivity(a.RecordLookup, "8.8.8.8", "run-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := val.Get(&ids[k]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ids[0] != "run-1" || ids[1] != ids[0] {
+		t.Errorf("record ids = %v, want run-1 twice", ids)
+	}
+	if len(a.cache) != 1 {
+		t.Errorf("cache = %v, want one record", a.cache)
+	}
+}
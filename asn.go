@@ -0,0 +1,58 @@
+package iplocate
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrASNNotFound is the application error type LookupASN returns for AS
+// numbers missing from the dataset.
+const ErrASNNotFound = "ASNNotFound"
+
+// LoadASNames reads an AS number to organization dataset in the format of
+// the RIPE/Team Cymru asn.txt exports: one "<asn> <name>" per line, e.g.
+// "15169 GOOGLE, US". An "AS" prefix on the number is accepted; blank
+// lines and lines starting with # are skipped.
+func LoadASNames(path string) (map[int]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	names := make(map[int]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		num, name, _ := strings.Cut(text, " ")
+		asn, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(num), "AS"))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid AS number %q", path, line, num)
+		}
+		names[asn] = strings.TrimSpace(name)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return names, nil
+}
+
+// LookupASN returns the organization name for asn from ASNames, without
+// touching the network.
+func (i *IPActivities) LookupASN(ctx context.Context, asn int) (string, error) {
+	name, ok := i.ASNames[asn]
+	if !ok {
+		return "", temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("AS%d not in the ASN dataset", asn), ErrASNNotFound, nil)
+	}
+	return name, nil
+}
@@ -0,0 +1,46 @@
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestIPActivities_LookupASN(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "asn.txt")
+	data := "# asn names\n15169 GOOGLE, US\nAS36972 MTN-SD, SD\n\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	names, err := LoadASNames(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &IPActivities{ASNames: names}
+
+	for asn, want := range map[int]string{15169: "GOOGLE, US", 36972: "MTN-SD, SD"} {
+		got, err := a.LookupASN(context.Background(), asn)
+		if err != nil || got != want {
+			t.Errorf("LookupASN(%d) = %q, %v; want %q", asn, got, err, want)
+		}
+	}
+
+	var appErr *temporal.ApplicationError
+	if _, err := a.LookupASN(context.Background(), 64512); !errors.As(err, &appErr) || appErr.Type() != ErrASNNotFound {
+		t.Errorf("err = %v, want %s", err, ErrASNNotFound)
+	}
+}
+
+func TestLoadASNames_InvalidNumber(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "asn.txt")
+	if err := os.WriteFile(path, []byte("15169 GOOGLE, US\nnope OTHER\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadASNames(path); err == nil {
+		t.Error("expected an error for a non-numeric AS number")
+	}
+}
@@ -0,0 +1,43 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// AuditRecord is the single record kept for each completed lookup.
+// Attempt is the workflow's own attempt number; activity retry counts are
+// not visible to workflow code and are left to the activity logs.
+type AuditRecord struct {
+	WorkflowID string
+	RunID      string
+	Attempt    int32
+	IP         string
+	Location   string
+	Timezone   string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	DurationMs int64
+}
+
+// EmitAudit appends record as a JSON line to IPActivities.AuditLog, or logs
+// it through the activity logger when no AuditLog is set.
+func (i *IPActivities) EmitAudit(ctx context.Context, record AuditRecord) error {
+	if i.AuditLog == nil {
+		activity.GetLogger(ctx).Info("audit", "record", record)
+		return nil
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal audit record: %w", err)
+	}
+	i.auditMu.Lock()
+	defer i.auditMu.Unlock()
+	_, err = i.AuditLog.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,45 @@
+package iplocate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestGetAddressFromIPV2_EmitsAudit(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.OnActivity("GetIP", mock.Anything).Return("8.8.8.8", nil)
+	env.OnActivity("RecordLookup", mock.Anything, "8.8.8.8", mock.Anything).Return("1-8.8.8.8", nil)
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("City: Ashburn", nil)
+	env.OnActivity("GetTimeZone", mock.Anything, "8.8.8.8").Return("America/New_York", nil)
+
+	var got AuditRecord
+	env.OnActivity("EmitAudit", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		got = args.Get(1).(AuditRecord)
+	})
+
+	env.ExecuteWorkflow(GetAddressFromIPV2, "", LookupOptions{})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	env.AssertExpectations(t)
+
+	if got.WorkflowID != "default-test-workflow-id" || got.RunID != "default-test-run-id" {
+		t.Errorf("execution = %s/%s", got.WorkflowID, got.RunID)
+	}
+	if got.IP != "8.8.8.8" || got.Location != "City: Ashburn" || got.Timezone != "America/New_York" {
+		t.Errorf("lookup fields = %+v", got)
+	}
+	if got.Attempt != 1 {
+		t.Errorf("attempt = %d, want 1", got.Attempt)
+	}
+	// The demo sleep alone takes 30s of workflow time.
+	if got.DurationMs < 30000 || !got.FinishedAt.After(got.StartedAt) {
+		t.Errorf("timing = %s..%s (%dms)", got.StartedAt, got.FinishedAt, got.DurationMs)
+	}
+}
@@ -0,0 +1,65 @@
+package iplocate
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// BackfillRecord is a lookup to import: IP as it would have been looked
+// up at At.
+type BackfillRecord struct {
+	IP string
+	At time.Time
+}
+
+// BackfillWorkflow looks up each record's IP and emits an AuditRecord
+// stamped with the record's At rather than the current time, for
+// importing an existing lookup history. Every At must be in the past.
+// Records that fail are skipped and reported together in the error.
+func BackfillWorkflow(ctx workflow.Context, records []BackfillRecord) error {
+	now := workflow.Now(ctx)
+	for k, r := range records {
+		if net.ParseIP(r.IP) == nil {
+			return temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("record %d: invalid ip %q", k, r.IP), ErrInvalidInput, nil)
+		}
+		if r.At.IsZero() || !r.At.Before(now) {
+			return temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("record %d: timestamp %s is not in the past", k, r.At), ErrInvalidInput, nil)
+		}
+	}
+
+	ao := ActivityConfig{}.Options()
+	var ipActivities *IPActivities
+	ctx = workflow.WithActivityOptions(ctx, ao)
+	info := workflow.GetInfo(ctx)
+
+	var errs []error
+	for _, r := range records {
+		var location string
+		err := workflow.ExecuteActivity(ctx, ipActivities.GetLocationInfo, r.IP).Get(ctx, &location)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("look up %s: %w", r.IP, err))
+			continue
+		}
+
+		record := AuditRecord{
+			WorkflowID: info.WorkflowExecution.ID,
+			RunID:      info.WorkflowExecution.RunID,
+			Attempt:    info.Attempt,
+			IP:         r.IP,
+			Location:   location,
+			StartedAt:  r.At,
+			FinishedAt: r.At,
+		}
+		if err := workflow.ExecuteActivity(ctx, ipActivities.EmitAudit, record).Get(ctx, nil); err != nil {
+			errs = append(errs, fmt.Errorf("record %s: %w", r.IP, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,54 @@
+package iplocate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestBackfillWorkflow(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	first := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	second := time.Date(2024, 3, 2, 17, 30, 0, 0, time.UTC)
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("City: Mountain View", nil)
+	env.OnActivity("GetLocationInfo", mock.Anything, "1.1.1.1").Return("City: Sydney", nil)
+
+	recorded := map[string]time.Time{}
+	env.OnActivity("EmitAudit", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		r := args.Get(1).(AuditRecord)
+		if !r.StartedAt.Equal(r.FinishedAt) {
+			t.Errorf("%s: StartedAt %s != FinishedAt %s", r.IP, r.StartedAt, r.FinishedAt)
+		}
+		recorded[r.IP] = r.StartedAt
+	})
+
+	env.ExecuteWorkflow(BackfillWorkflow, []BackfillRecord{
+		{IP: "8.8.8.8", At: first},
+		{IP: "1.1.1.1", At: second},
+	})
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+
+	if !recorded["8.8.8.8"].Equal(first) || !recorded["1.1.1.1"].Equal(second) {
+		t.Errorf("recorded timestamps = %v, want the provided ones", recorded)
+	}
+}
+
+func TestBackfillWorkflow_RejectsFutureTimestamps(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.ExecuteWorkflow(BackfillWorkflow, []BackfillRecord{
+		{IP: "8.8.8.8", At: env.Now().Add(time.Hour)},
+	})
+	if err := env.GetWorkflowError(); err == nil {
+		t.Error("expected a future timestamp to be rejected")
+	}
+}
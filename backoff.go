@@ -0,0 +1,44 @@
+package iplocate
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+// backoffJitter is the largest fraction Backoff takes off a delay.
+const backoffJitter = 0.2
+
+// Backoff returns how long an in-workflow retry loop should sleep before
+// attempt (counting from 1): base doubled per attempt up to max, less up to
+// 20% jitter. The jitter comes from the run ID and is recorded with
+// SideEffect, so workflows started together don't retry in lockstep while
+// a replay waits exactly as long as the original run did.
+func Backoff(ctx workflow.Context, attempt int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	runID := workflow.GetInfo(ctx).WorkflowExecution.RunID
+	var frac float64
+	encoded := workflow.SideEffect(ctx, func(workflow.Context) any {
+		return jitterFraction(runID, attempt)
+	})
+	if err := encoded.Get(&frac); err != nil {
+		panic(err)
+	}
+	return d - time.Duration(float64(d)*backoffJitter*frac)
+}
+
+// jitterFraction maps runID and attempt to [0, 1).
+func jitterFraction(runID string, attempt int) float64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s/%d", runID, attempt)
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
@@ -0,0 +1,63 @@
+package iplocate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func backoffWorkflow(ctx workflow.Context, attempts int) ([]time.Duration, error) {
+	var delays []time.Duration
+	for attempt := 1; attempt <= attempts; attempt++ {
+		delays = append(delays, Backoff(ctx, attempt, time.Second, 10*time.Second))
+	}
+	return delays, nil
+}
+
+func runBackoffWorkflow(t *testing.T) []time.Duration {
+	t.Helper()
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(backoffWorkflow)
+
+	env.ExecuteWorkflow(backoffWorkflow, 5)
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	var delays []time.Duration
+	if err := env.GetWorkflowResult(&delays); err != nil {
+		t.Fatal(err)
+	}
+	return delays
+}
+
+func TestBackoff(t *testing.T) {
+	delays := runBackoffWorkflow(t)
+
+	caps := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+	for k, d := range delays {
+		if d > caps[k] || d < caps[k]*8/10 {
+			t.Errorf("attempt %d: delay %s outside [80%%, 100%%] of %s", k+1, d, caps[k])
+		}
+	}
+
+	// The same run computes the same jitter, as a replay must.
+	if again := runBackoffWorkflow(t); !reflect.DeepEqual(delays, again) {
+		t.Errorf("delays differ for the same run: %v vs %v", delays, again)
+	}
+}
+
+func TestJitterFraction(t *testing.T) {
+	a, b := jitterFraction("run-a", 1), jitterFraction("run-b", 1)
+	if a == b {
+		t.Errorf("runs share jitter %v", a)
+	}
+	for _, f := range []float64{a, b, jitterFraction("run-a", 2)} {
+		if f < 0 || f >= 1 {
+			t.Errorf("jitter fraction %v outside [0, 1)", f)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package iplocate
+
+import (
+	"fmt"
+	"net"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// BlocklistResult says whether an IP is blocklisted, and by which CIDR.
+type BlocklistResult struct {
+	Blocked bool
+	CIDR    string
+}
+
+// MatchCIDR returns the first of cidrs containing ip, or "" when none
+// does. It does no I/O, so it is safe to call from workflow code.
+func MatchCIDR(ip string, cidrs []string) (string, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", fmt.Errorf("invalid ip %q", ip)
+	}
+	for _, c := range cidrs {
+		_, network, err := net.ParseCIDR(c)
+		if err != nil {
+			return "", fmt.Errorf("invalid cidr %q: %w", c, err)
+		}
+		if network.Contains(parsed) {
+			return c, nil
+		}
+	}
+	return "", nil
+}
+
+// BlocklistCheckWorkflow checks ip against cidrs and logs an alert when it
+// is blocked. Malformed input fails with ErrInvalidInput.
+func BlocklistCheckWorkflow(ctx workflow.Context, ip string, cidrs []string) (BlocklistResult, error) {
+	match, err := MatchCIDR(ip, cidrs)
+	if err != nil {
+		return BlocklistResult{}, temporal.NewNonRetryableApplicationError(err.Error(), ErrInvalidInput, nil)
+	}
+	if match == "" {
+		return BlocklistResult{}, nil
+	}
+
+	workflow.GetLogger(ctx).Warn("ALERT: IP is blocklisted", "ip", ip, "cidr", match)
+	return BlocklistResult{Blocked: true, CIDR: match}, nil
+}
@@ -0,0 +1,37 @@
+package iplocate
+
+import (
+	"testing"
+
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestBlocklistCheckWorkflow(t *testing.T) {
+	cidrs := []string{"203.0.113.0/24", "2001:db8::/32"}
+	for ip, want := range map[string]BlocklistResult{
+		"203.0.113.77": {Blocked: true, CIDR: "203.0.113.0/24"},
+		"2001:db8::1":  {Blocked: true, CIDR: "2001:db8::/32"},
+		"8.8.8.8":      {},
+	} {
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestWorkflowEnvironment()
+		env.ExecuteWorkflow(BlocklistCheckWorkflow, ip, cidrs)
+
+		var got BlocklistResult
+		if err := env.GetWorkflowResult(&got); err != nil {
+			t.Fatalf("%s: %v", ip, err)
+		}
+		if got != want {
+			t.Errorf("%s: got %+v, want %+v", ip, got, want)
+		}
+	}
+}
+
+func TestMatchCIDR_InvalidInput(t *testing.T) {
+	if _, err := MatchCIDR("8.8.8.8", []string{"8.8.8.0/33"}); err == nil {
+		t.Error("expected an error for an invalid cidr")
+	}
+	if _, err := MatchCIDR("nope", nil); err == nil {
+		t.Error("expected an error for an invalid ip")
+	}
+}
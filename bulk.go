@@ -0,0 +1,124 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+)
+
+// bulkChunkSize is the most IPs ip-api's batch endpoint takes per request.
+const bulkChunkSize = 100
+
+const bulkURL = "http://ip-api.com/batch?fields=status,message,query,country,countryCode,continent,continentCode,regionName,city,district,lat,lon,timezone"
+
+// GetLocationBulk geolocates ips with ip-api's batch endpoint, 100 IPs per
+// request, and returns the results in the order of ips. IPs that ip-api
+// can't locate, such as private ranges, come back with only IP set.
+//
+// It heartbeats the results gathered so far after every chunk, so a retry
+// resumes after the last completed chunk instead of starting over. When
+// ip-api reports its rate limit window as used up (X-Rl: 0) it waits out
+// X-Ttl before sending the next chunk. Use a HeartbeatTimeout longer than
+// that wait, i.e. over a minute.
+func (i *IPActivities) GetLocationBulk(ctx context.Context, ips []string) ([]LocationDetails, error) {
+	var results []LocationDetails
+	if activity.IsActivity(ctx) && activity.HasHeartbeatDetails(ctx) {
+		if err := activity.GetHeartbeatDetails(ctx, &results); err != nil {
+			results = nil
+		}
+	}
+
+	for len(results) < len(ips) {
+		end := min(len(results)+bulkChunkSize, len(ips))
+		chunk, wait, err := i.locateChunk(ctx, ips[len(results):end])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, chunk...)
+		if activity.IsActivity(ctx) {
+			activity.RecordHeartbeat(ctx, results)
+		}
+
+		if wait > 0 && len(results) < len(ips) {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	return results, nil
+}
+
+// locateChunk sends one batch request. wait is how long ip-api asks us to
+// hold off before the next one.
+func (i *IPActivities) locateChunk(ctx context.Context, ips []string) (results []LocationDetails, wait time.Duration, err error) {
+	payload, err := json.Marshal(ips)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := i.send(ctx, http.MethodPost, bulkURL, payload)
+	if err != nil {
+		return nil, 0, lookupError(CategoryNetwork, "HTTP POST", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("X-Rl") == "0" {
+		if ttl, err := strconv.Atoi(resp.Header.Get("X-Ttl")); err == nil {
+			wait = time.Duration(ttl) * time.Second
+		}
+	}
+
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return nil, 0, lookupError(CategoryNetwork, "read body", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, lookupError(CategoryProviderFail, "HTTP POST", fmt.Errorf("%s: %s", bulkURL, resp.Status))
+	}
+
+	var data []struct {
+		Status        string  `json:"status"`
+		Query         string  `json:"query"`
+		City          string  `json:"city"`
+		District      string  `json:"district"`
+		Region        string  `json:"regionName"`
+		Country       string  `json:"country"`
+		CountryCode   string  `json:"countryCode"`
+		Continent     string  `json:"continent"`
+		ContinentCode string  `json:"continentCode"`
+		Timezone      string  `json:"timezone"`
+		Lat           float64 `json:"lat"`
+		Lon           float64 `json:"lon"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, 0, lookupError(CategoryParse, "JSON unmarshal", err)
+	}
+	if len(data) != len(ips) {
+		return nil, 0, lookupError(CategoryParse, "JSON unmarshal", fmt.Errorf("got %d results for %d IPs", len(data), len(ips)))
+	}
+
+	results = make([]LocationDetails, len(ips))
+	for k, d := range data {
+		results[k] = LocationDetails{IP: ips[k]}
+		if d.Status == "fail" {
+			continue
+		}
+		results[k].City = d.City
+		results[k].District = d.District
+		results[k].Region = d.Region
+		results[k].Country = d.Country
+		results[k].CountryCode = d.CountryCode
+		results[k].Continent = d.Continent
+		results[k].ContinentCode = d.ContinentCode
+		results[k].Timezone = d.Timezone
+		results[k].Lat = d.Lat
+		results[k].Lon = d.Lon
+	}
+	return results, wait, nil
+}
@@ -0,0 +1,65 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// batchDoer answers ip-api batch requests, naming each city after its IP.
+type batchDoer struct {
+	chunks []int
+}
+
+func (b *batchDoer) Get(url string) (*http.Response, error) {
+	return nil, fmt.Errorf("unexpected GET %s", url)
+}
+
+func (b *batchDoer) Do(req *http.Request) (*http.Response, error) {
+	var ips []string
+	if err := json.NewDecoder(req.Body).Decode(&ips); err != nil {
+		return nil, err
+	}
+	b.chunks = append(b.chunks, len(ips))
+
+	results := make([]map[string]string, len(ips))
+	for k, ip := range ips {
+		results[k] = map[string]string{"status": "success", "query": ip, "city": "city-" + ip}
+	}
+	body, _ := json.Marshal(results)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"X-Rl": {"14"}, "X-Ttl": {"60"}},
+		Body:       io.NopCloser(strings.NewReader(string(body))),
+	}, nil
+}
+
+func TestIPActivities_GetLocationBulk(t *testing.T) {
+	ips := make([]string, 250)
+	for k := range ips {
+		ips[k] = fmt.Sprintf("10.0.%d.%d", k/256, k%256)
+	}
+	doer := &batchDoer{}
+	a := &IPActivities{HTTPClient: doer}
+
+	got, err := a.GetLocationBulk(context.Background(), ips)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(doer.chunks) != "[100 100 50]" {
+		t.Errorf("chunk sizes = %v, want [100 100 50]", doer.chunks)
+	}
+	if len(got) != len(ips) {
+		t.Fatalf("got %d results, want %d", len(got), len(ips))
+	}
+	for k, d := range got {
+		if d.IP != ips[k] || d.City != "city-"+ips[k] {
+			t.Fatalf("result %d = %+v, want %s", k, d, ips[k])
+		}
+	}
+}
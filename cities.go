@@ -0,0 +1,129 @@
+package iplocate
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+type city struct {
+	Name     string
+	Lat, Lon float64
+}
+
+// majorCities is a small bundled dataset so NearbyCities needs no network.
+var majorCities = []city{
+	{"Tokyo, Japan", 35.6762, 139.6503},
+	{"Osaka, Japan", 34.6937, 135.5023},
+	{"Seoul, South Korea", 37.5665, 126.9780},
+	{"Beijing, China", 39.9042, 116.4074},
+	{"Shanghai, China", 31.2304, 121.4737},
+	{"Hong Kong", 22.3193, 114.1694},
+	{"Taipei, Taiwan", 25.0330, 121.5654},
+	{"Manila, Philippines", 14.5995, 120.9842},
+	{"Bangkok, Thailand", 13.7563, 100.5018},
+	{"Ho Chi Minh City, Vietnam", 10.8231, 106.6297},
+	{"Singapore", 1.3521, 103.8198},
+	{"Jakarta, Indonesia", -6.2088, 106.8456},
+	{"Sydney, Australia", -33.8688, 151.2093},
+	{"Melbourne, Australia", -37.8136, 144.9631},
+	{"Auckland, New Zealand", -36.8485, 174.7633},
+	{"Delhi, India", 28.7041, 77.1025},
+	{"Mumbai, India", 19.0760, 72.8777},
+	{"Bangalore, India", 12.9716, 77.5946},
+	{"Karachi, Pakistan", 24.8607, 67.0011},
+	{"Dhaka, Bangladesh", 23.8103, 90.4125},
+	{"Tehran, Iran", 35.6892, 51.3890},
+	{"Dubai, United Arab Emirates", 25.2048, 55.2708},
+	{"Riyadh, Saudi Arabia", 24.7136, 46.6753},
+	{"Istanbul, Turkey", 41.0082, 28.9784},
+	{"Cairo, Egypt", 30.0444, 31.2357},
+	{"Khartoum, Sudan", 15.5007, 32.5599},
+	{"Addis Ababa, Ethiopia", 8.9806, 38.7578},
+	{"Nairobi, Kenya", -1.2921, 36.8219},
+	{"Lagos, Nigeria", 6.5244, 3.3792},
+	{"Accra, Ghana", 5.6037, -0.1870},
+	{"Kinshasa, DR Congo", -4.4419, 15.2663},
+	{"Johannesburg, South Africa", -26.2041, 28.0473},
+	{"Cape Town, South Africa", -33.9249, 18.4241},
+	{"Casablanca, Morocco", 33.5731, -7.5898},
+	{"Moscow, Russia", 55.7558, 37.6173},
+	{"Saint Petersburg, Russia", 59.9311, 30.3609},
+	{"Kyiv, Ukraine", 50.4501, 30.5234},
+	{"Warsaw, Poland", 52.2297, 21.0122},
+	{"Berlin, Germany", 52.5200, 13.4050},
+	{"Frankfurt, Germany", 50.1109, 8.6821},
+	{"Amsterdam, Netherlands", 52.3676, 4.9041},
+	{"Brussels, Belgium", 50.8503, 4.3517},
+	{"Paris, France", 48.8566, 2.3522},
+	{"London, United Kingdom", 51.5074, -0.1278},
+	{"Dublin, Ireland", 53.3498, -6.2603},
+	{"Madrid, Spain", 40.4168, -3.7038},
+	{"Lisbon, Portugal", 38.7223, -9.1393},
+	{"Rome, Italy", 41.9028, 12.4964},
+	{"Milan, Italy", 45.4642, 9.1900},
+	{"Vienna, Austria", 48.2082, 16.3738},
+	{"Zurich, Switzerland", 47.3769, 8.5417},
+	{"Stockholm, Sweden", 59.3293, 18.0686},
+	{"Oslo, Norway", 59.9139, 10.7522},
+	{"Helsinki, Finland", 60.1699, 24.9384},
+	{"Athens, Greece", 37.9838, 23.7275},
+	{"New York, United States", 40.7128, -74.0060},
+	{"Washington, United States", 38.9072, -77.0369},
+	{"Chicago, United States", 41.8781, -87.6298},
+	{"Atlanta, United States", 33.7490, -84.3880},
+	{"Miami, United States", 25.7617, -80.1918},
+	{"Dallas, United States", 32.7767, -96.7970},
+	{"Denver, United States", 39.7392, -104.9903},
+	{"Los Angeles, United States", 34.0522, -118.2437},
+	{"San Francisco, United States", 37.7749, -122.4194},
+	{"Seattle, United States", 47.6062, -122.3321},
+	{"Toronto, Canada", 43.6532, -79.3832},
+	{"Montreal, Canada", 45.5017, -73.5673},
+	{"Vancouver, Canada", 49.2827, -123.1207},
+	{"Mexico City, Mexico", 19.4326, -99.1332},
+	{"Bogota, Colombia", 4.7110, -74.0721},
+	{"Lima, Peru", -12.0464, -77.0428},
+	{"Santiago, Chile", -33.4489, -70.6693},
+	{"Buenos Aires, Argentina", -34.6037, -58.3816},
+	{"Sao Paulo, Brazil", -23.5505, -46.6333},
+	{"Rio de Janeiro, Brazil", -22.9068, -43.1729},
+}
+
+const earthRadiusKm = 6371.0
+
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := rad(lat2 - lat1)
+	dLon := rad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// NearbyCities returns the n bundled major cities closest to lat/lon,
+// nearest first. It makes no network calls.
+func (i *IPActivities) NearbyCities(ctx context.Context, lat, lon float64, n int) ([]string, error) {
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return nil, fmt.Errorf("coordinates out of range: %v, %v", lat, lon)
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("n must be positive, got %d", n)
+	}
+
+	sorted := make([]city, len(majorCities))
+	copy(sorted, majorCities)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		return haversineKm(lat, lon, sorted[a].Lat, sorted[a].Lon) < haversineKm(lat, lon, sorted[b].Lat, sorted[b].Lon)
+	})
+
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+	names := make([]string, n)
+	for k := range names {
+		names[k] = sorted[k].Name
+	}
+	return names, nil
+}
@@ -0,0 +1,36 @@
+package iplocate
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestHaversineKm(t *testing.T) {
+	// London to Paris is about 344km.
+	if d := haversineKm(51.5074, -0.1278, 48.8566, 2.3522); math.Abs(d-344) > 5 {
+		t.Errorf("London-Paris = %.0fkm, want ~344km", d)
+	}
+}
+
+func TestIPActivities_NearbyCities(t *testing.T) {
+	a := &IPActivities{}
+
+	// Somewhere in Kent, between London and the Channel.
+	got, err := a.NearbyCities(context.Background(), 51.27, 0.52, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"London, United Kingdom", "Brussels, Belgium", "Paris, France"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NearbyCities = %v, want %v", got, want)
+	}
+
+	if _, err := a.NearbyCities(context.Background(), 91, 0, 3); err == nil {
+		t.Error("expected an error for out-of-range latitude")
+	}
+	if _, err := a.NearbyCities(context.Background(), 0, 0, 0); err == nil {
+		t.Error("expected an error for n = 0")
+	}
+}
@@ -0,0 +1,237 @@
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+// DefaultClientTimeout bounds a single call to the Temporal frontend so a
+// degraded cluster can't hang a CLI forever.
+const DefaultClientTimeout = 10 * time.Second
+
+var ErrClientTimeout = errors.New("temporal client call timed out")
+
+// CallWithTimeout runs fn with ctx bounded by timeout (DefaultClientTimeout
+// when zero). If the deadline is what stopped fn, the returned error wraps
+// ErrClientTimeout.
+func CallWithTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		timeout = DefaultClientTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := fn(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s: %v", ErrClientTimeout, timeout, err)
+	}
+	return err
+}
+
+var ErrResultTimeout = errors.New("timed out waiting for workflow result")
+
+// AwaitResult is we.Get bounded by timeout; zero or less waits as long as
+// ctx allows. When timeout, rather than ctx ending, is what stopped the
+// wait the returned error wraps ErrResultTimeout. The workflow itself is
+// left running: AwaitResult only stops waiting. Use AwaitResultOrCancel to
+// cancel it too.
+func AwaitResult(ctx context.Context, we client.WorkflowRun, out any, timeout time.Duration) error {
+	if timeout <= 0 {
+		return we.Get(ctx, out)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := we.Get(waitCtx, out)
+	if err != nil && ctx.Err() == nil && errors.Is(waitCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %s after %s", ErrResultTimeout, we.GetID(), timeout)
+	}
+	return err
+}
+
+// AwaitResultOrCancel is AwaitResult that also requests cancellation of
+// the run when the wait times out. It doesn't wait for the cancellation to
+// take effect.
+func AwaitResultOrCancel(ctx context.Context, c client.Client, we client.WorkflowRun, out any, timeout time.Duration) error {
+	err := AwaitResult(ctx, we, out, timeout)
+	if !errors.Is(err, ErrResultTimeout) {
+		return err
+	}
+	// ctx may be the one that just expired.
+	cancelErr := CallWithTimeout(context.WithoutCancel(ctx), 0, func(ctx context.Context) error {
+		return c.CancelWorkflow(ctx, we.GetID(), we.GetRunID())
+	})
+	if cancelErr != nil {
+		return fmt.Errorf("%w (cancel failed: %v)", err, cancelErr)
+	}
+	return err
+}
+
+// DefaultDialAttempts gives a Temporal server that is still starting about
+// a minute and a half to come up.
+const DefaultDialAttempts = 8
+
+// DialAttemptsEnv overrides DefaultDialAttempts in DialAttemptsFromEnv.
+const DialAttemptsEnv = "DIAL_ATTEMPTS"
+
+// DialAttemptsFromEnv returns the attempt count for DialWithRetry from
+// DialAttemptsEnv, or DefaultDialAttempts when it is unset.
+func DialAttemptsFromEnv() (int, error) {
+	n, err := positiveEnv(DialAttemptsEnv, 1)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return DefaultDialAttempts, nil
+	}
+	return n, nil
+}
+
+const maxDialBackoff = 30 * time.Second
+
+// Overridden in tests.
+var (
+	dial        = Dial
+	dialBackoff = time.Second
+)
+
+// DialWithRetry calls Dial up to maxAttempts times, doubling the wait
+// between attempts from 1s up to 30s. It lets workers and starters come up
+// before the Temporal server does, as in docker-compose or Kubernetes.
+func DialWithRetry(opts client.Options, maxAttempts int) (client.Client, error) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	wait := dialBackoff
+	for attempt := 1; ; attempt++ {
+		c, err := dial(opts)
+		if err == nil {
+			return c, nil
+		}
+		if attempt == maxAttempts {
+			return nil, fmt.Errorf("dial %s: giving up after %d attempts: %w", opts.HostPort, attempt, err)
+		}
+		log.Printf("Dial %s failed (attempt %d/%d), retrying in %s: %v", opts.HostPort, attempt, maxAttempts, wait, err)
+		time.Sleep(wait)
+		wait = min(wait*2, maxDialBackoff)
+	}
+}
+
+// maxWorkflowIDLength is the default server-side limit on workflow IDs.
+const maxWorkflowIDLength = 1000
+
+// IDGenerator supplies the unique part of workflow IDs. Tests can inject a
+// fixed sequence to make IDs predictable.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc adapts a function such as uuid.NewString to IDGenerator.
+type IDGeneratorFunc func() string
+
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// DefaultIDGenerator returns random UUIDs.
+var DefaultIDGenerator IDGenerator = IDGeneratorFunc(uuid.NewString)
+
+// WorkflowID returns prefix + "-" + an ID from gen, or from
+// DefaultIDGenerator when gen is nil.
+func WorkflowID(prefix string, gen IDGenerator) string {
+	if gen == nil {
+		gen = DefaultIDGenerator
+	}
+	return prefix + "-" + gen.NewID()
+}
+
+// ValidateStartOptions catches option mistakes client-side, before
+// ExecuteWorkflow would have the server reject them.
+func ValidateStartOptions(opts client.StartWorkflowOptions) error {
+	switch {
+	case opts.ID == "":
+		return errors.New("workflow ID is required")
+	case len(opts.ID) > maxWorkflowIDLength:
+		return fmt.Errorf("workflow ID is %d bytes, limit is %d", len(opts.ID), maxWorkflowIDLength)
+	case opts.TaskQueue == "":
+		return errors.New("task queue is required")
+	case opts.StartDelay < 0:
+		return fmt.Errorf("start delay must not be negative, got %s", opts.StartDelay)
+	}
+	return nil
+}
+
+// GetResult returns the result of the latest run of a lookup workflow. A
+// running workflow is waited on. For a closed run the result is read from
+// its completion event in history, which stays available for the
+// namespace's retention period: retention is set per namespace (e.g.
+// `temporal operator namespace update --retention 72h default`), there is
+// no per-workflow option for it in StartWorkflowOptions.
+func GetResult(ctx context.Context, c client.Client, workflowID string) (Data, error) {
+	desc, err := c.DescribeWorkflowExecution(ctx, workflowID, "")
+	if err != nil {
+		return Data{}, fmt.Errorf("describe workflow %s: %w", workflowID, err)
+	}
+	// Pin the run so a new run started under the same ID can't answer.
+	runID := desc.GetWorkflowExecutionInfo().GetExecution().GetRunId()
+
+	var result Data
+	if err := c.GetWorkflow(ctx, workflowID, runID).Get(ctx, &result); err != nil {
+		return Data{}, err
+	}
+	return result, nil
+}
+
+// LookupIDPrefix starts entity-based lookup workflow IDs; see
+// LookupWorkflowID.
+const LookupIDPrefix = "ip-lookup"
+
+var ErrNoLookupFound = errors.New("no lookup found")
+
+// LookupWorkflowID is the entity-based workflow ID for ip, such as
+// "ip-lookup-8.8.8.8". Starting lookups under it lets ResultForIP find them
+// by IP alone.
+func LookupWorkflowID(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil {
+		ip = parsed.String()
+	}
+	return WorkflowID(LookupIDPrefix, IDGeneratorFunc(func() string { return ip }))
+}
+
+// ResultForIP is GetResult for the workflow started under
+// LookupWorkflowID(ip). If no such workflow exists, or its history is past
+// retention, the error wraps ErrNoLookupFound.
+func ResultForIP(ctx context.Context, c client.Client, ip string) (Data, error) {
+	result, err := GetResult(ctx, c, LookupWorkflowID(ip))
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return Data{}, fmt.Errorf("%s: %w", ip, ErrNoLookupFound)
+	}
+	return result, err
+}
+
+// CancelLookup asks the latest run of the lookup workflow id to stop. It
+// returns once the request is accepted, not when the run has ended. A run
+// that notices ends with a CanceledError whose details hold what it found
+// so far: the IP for GetAddressFromIP, a partial Data for
+// GetAddressFromIPV2. An activity in the middle of a request learns of the
+// cancel on its next heartbeat, within LookupHeartbeatTimeout, and the
+// run waits for it to stop. If no such workflow exists, the error wraps
+// ErrNoLookupFound.
+func CancelLookup(ctx context.Context, c client.Client, id string) error {
+	err := CallWithTimeout(ctx, 0, func(ctx context.Context) error {
+		return c.CancelWorkflow(ctx, id, "")
+	})
+	var notFound *serviceerror.NotFound
+	if errors.As(err, &notFound) {
+		return fmt.Errorf("%s: %w", id, ErrNoLookupFound)
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+//go:build integration
+
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+)
+
+// Run with a dev server up: temporal server start-dev, then
+// go test -tags integration -run Integration ./...
+func TestResultForIP_Integration(t *testing.T) {
+	c, err := Dial(client.Options{HostPort: "127.0.0.1:7233", Namespace: "default"})
+	if err != nil {
+		t.Skipf("no Temporal server: %v", err)
+	}
+	defer c.Close()
+
+	// The worker's own egress IP differs from the one looked up, so a
+	// workflow that ignored LookupOptions.IP would be caught.
+	ip, egressIP := "8.8.8.8", "203.0.113.7"
+	taskQueue := WorkflowID("integration", nil)
+	w := worker.New(c, taskQueue, worker.Options{})
+	RegisterWorkflows(w)
+	w.RegisterActivity(&IPActivities{HTTPClient: &stubGetter{bodies: map[string]string{
+		"https://api.ipify.org":                             egressIP,
+		"http://ip-api.com/json/" + ip:                      `{"status":"success","city":"Mountain View","regionName":"California","country":"United States"}`,
+		"http://ip-api.com/json/" + ip + "?fields=timezone": `{"status":"success","timezone":"America/Los_Angeles"}`,
+	}}})
+	if err := w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	run, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:        LookupWorkflowID(ip),
+		TaskQueue: taskQueue,
+	}, GetAddressFromIPV2, "", LookupOptions{IP: ip, DemoSleep: NoDemoSleep})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := run.Get(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ResultForIP(ctx, c, ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Result != ip || got.Location == "" || got.Zone != "America/Los_Angeles" {
+		t.Errorf("ResultForIP = %+v", got)
+	}
+
+	if _, err := ResultForIP(ctx, c, "192.0.2.123"); !errors.Is(err, ErrNoLookupFound) {
+		t.Errorf("never looked up: err = %v, want ErrNoLookupFound", err)
+	}
+}
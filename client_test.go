@@ -0,0 +1,247 @@
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/mocks"
+)
+
+func TestCallWithTimeout_WrapsDeadline(t *testing.T) {
+	err := CallWithTimeout(context.Background(), 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, ErrClientTimeout) {
+		t.Fatalf("err = %v, want ErrClientTimeout", err)
+	}
+}
+
+func TestCallWithTimeout_PassesThroughOtherErrors(t *testing.T) {
+	want := errors.New("boom")
+	err := CallWithTimeout(context.Background(), time.Second, func(ctx context.Context) error {
+		return want
+	})
+	if err != want {
+		t.Fatalf("err = %v, want %v", err, want)
+	}
+}
+
+func TestValidateStartOptions(t *testing.T) {
+	valid := client.StartWorkflowOptions{ID: "ip-lookup-1", TaskQueue: TaskQueueName}
+	if err := ValidateStartOptions(valid); err != nil {
+		t.Fatalf("valid options rejected: %v", err)
+	}
+
+	tests := map[string]func(o *client.StartWorkflowOptions){
+		"missing ID":         func(o *client.StartWorkflowOptions) { o.ID = "" },
+		"ID too long":        func(o *client.StartWorkflowOptions) { o.ID = strings.Repeat("x", maxWorkflowIDLength+1) },
+		"missing task queue": func(o *client.StartWorkflowOptions) { o.TaskQueue = "" },
+		"negative delay":     func(o *client.StartWorkflowOptions) { o.StartDelay = -time.Second },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := valid
+			mutate(&opts)
+			if err := ValidateStartOptions(opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestGetResult_CompletedWorkflow(t *testing.T) {
+	c := &mocks.Client{}
+	c.On("DescribeWorkflowExecution", mock.Anything, "ip-lookup-1", "").
+		Return(&workflowservice.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{
+				Execution: &commonpb.WorkflowExecution{WorkflowId: "ip-lookup-1", RunId: "run-1"},
+				Status:    enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED,
+			},
+		}, nil)
+
+	want := Data{Result: "8.8.8.8", Location: "City: Ashburn", Zone: "America/New_York"}
+	run := &mocks.WorkflowRun{}
+	run.On("Get", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*Data) = want
+	})
+	c.On("GetWorkflow", mock.Anything, "ip-lookup-1", "run-1").Return(run)
+
+	got, err := GetResult(context.Background(), c, "ip-lookup-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("GetResult = %+v, want %+v", got, want)
+	}
+}
+
+func TestDialWithRetry_SucceedsAfterFailures(t *testing.T) {
+	origDial, origBackoff := dial, dialBackoff
+	defer func() { dial, dialBackoff = origDial, origBackoff }()
+
+	attempts := 0
+	want := &mocks.Client{}
+	dial = func(opts client.Options) (client.Client, error) {
+		attempts++
+		if attempts <= 2 {
+			return nil, errors.New("connection refused")
+		}
+		return want, nil
+	}
+	dialBackoff = time.Millisecond
+
+	c, err := DialWithRetry(client.Options{HostPort: "127.0.0.1:7233"}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != want {
+		t.Error("returned a different client")
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestDialWithRetry_GivesUp(t *testing.T) {
+	origDial, origBackoff := dial, dialBackoff
+	defer func() { dial, dialBackoff = origDial, origBackoff }()
+
+	attempts := 0
+	dial = func(opts client.Options) (client.Client, error) {
+		attempts++
+		return nil, errors.New("connection refused")
+	}
+	dialBackoff = time.Millisecond
+
+	if _, err := DialWithRetry(client.Options{}, 3); err == nil {
+		t.Fatal("expected an error")
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestWorkflowID(t *testing.T) {
+	n := 0
+	gen := IDGeneratorFunc(func() string {
+		n++
+		return fmt.Sprintf("id-%d", n)
+	})
+
+	for _, want := range []string{"lookup-id-1", "lookup-id-2"} {
+		if got := WorkflowID("lookup", gen); got != want {
+			t.Errorf("WorkflowID = %q, want %q", got, want)
+		}
+	}
+
+	if a, b := WorkflowID("lookup", nil), WorkflowID("lookup", nil); a == b {
+		t.Errorf("default generator repeated %q", a)
+	}
+}
+
+func TestResultForIP_NotFound(t *testing.T) {
+	c := &mocks.Client{}
+	c.On("DescribeWorkflowExecution", mock.Anything, "ip-lookup-8.8.4.4", "").
+		Return(nil, serviceerror.NewNotFound("workflow not found"))
+
+	if _, err := ResultForIP(context.Background(), c, "8.8.4.4"); !errors.Is(err, ErrNoLookupFound) {
+		t.Errorf("err = %v, want ErrNoLookupFound", err)
+	}
+}
+
+// slowRun is a workflow run that outlives any wait on it.
+func slowRun() *mocks.WorkflowRun {
+	run := &mocks.WorkflowRun{}
+	run.On("GetID").Return("ip-lookup-1")
+	run.On("GetRunID").Return("run-1")
+	run.On("Get", mock.Anything, mock.Anything).Return(context.DeadlineExceeded).Run(func(args mock.Arguments) {
+		<-args.Get(0).(context.Context).Done()
+	})
+	return run
+}
+
+func TestAwaitResult_Timeout(t *testing.T) {
+	var out Data
+	err := AwaitResult(context.Background(), slowRun(), &out, 10*time.Millisecond)
+	if !errors.Is(err, ErrResultTimeout) {
+		t.Fatalf("err = %v, want ErrResultTimeout", err)
+	}
+}
+
+func TestAwaitResultOrCancel(t *testing.T) {
+	c := &mocks.Client{}
+	c.On("CancelWorkflow", mock.Anything, "ip-lookup-1", "run-1").Return(nil).Once()
+
+	var out Data
+	err := AwaitResultOrCancel(context.Background(), c, slowRun(), &out, 10*time.Millisecond)
+	if !errors.Is(err, ErrResultTimeout) {
+		t.Fatalf("err = %v, want ErrResultTimeout", err)
+	}
+	c.AssertExpectations(t)
+
+	// A run that finishes in time is not cancelled.
+	done := &mocks.WorkflowRun{}
+	done.On("Get", mock.Anything, mock.Anything).Return(nil)
+	if err := AwaitResultOrCancel(context.Background(), c, done, &out, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	c.AssertNumberOfCalls(t, "CancelWorkflow", 1)
+}
+
+func TestCancelLookup(t *testing.T) {
+	c := &mocks.Client{}
+	c.On("CancelWorkflow", mock.Anything, "ip-lookup-8.8.8.8", "").Return(nil).Once()
+	c.On("CancelWorkflow", mock.Anything, "ip-lookup-8.8.4.4", "").Return(serviceerror.NewNotFound("workflow not found"))
+
+	if err := CancelLookup(context.Background(), c, LookupWorkflowID("8.8.8.8")); err != nil {
+		t.Fatal(err)
+	}
+	if err := CancelLookup(context.Background(), c, LookupWorkflowID("8.8.4.4")); !errors.Is(err, ErrNoLookupFound) {
+		t.Errorf("err = %v, want ErrNoLookupFound", err)
+	}
+	c.AssertExpectations(t)
+}
+
+func TestAwaitResultOrCancel_CallerDeadline(t *testing.T) {
+	c := &mocks.Client{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var out Data
+	err := AwaitResultOrCancel(ctx, c, slowRun(), &out, time.Minute)
+	if err == nil || errors.Is(err, ErrResultTimeout) {
+		t.Errorf("err = %v, want the caller's deadline rather than ErrResultTimeout", err)
+	}
+	c.AssertNotCalled(t, "CancelWorkflow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDialAttemptsFromEnv(t *testing.T) {
+	t.Setenv(DialAttemptsEnv, "")
+	if n, err := DialAttemptsFromEnv(); err != nil || n != DefaultDialAttempts {
+		t.Errorf("unset env: got %d, %v, want %d", n, err, DefaultDialAttempts)
+	}
+
+	t.Setenv(DialAttemptsEnv, "3")
+	if n, err := DialAttemptsFromEnv(); err != nil || n != 3 {
+		t.Errorf("got %d, %v, want 3", n, err)
+	}
+
+	for _, v := range []string{"0", "-1", "many"} {
+		t.Setenv(DialAttemptsEnv, v)
+		if _, err := DialAttemptsFromEnv(); err == nil {
+			t.Errorf("%s=%q: expected an error", DialAttemptsEnv, v)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package iplocate
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var ErrNoClientIP = errors.New("no public client ip in headers")
+
+// ClientIPFromHeaders picks the address to geolocate for a request that
+// reached us through reverse proxies, from X-Forwarded-For or, when that is
+// absent, X-Real-IP. It returns the first public address, skipping private
+// and reserved ones.
+//
+// Clients can send any X-Forwarded-For they like; only the entries our own
+// proxies appended are trustworthy. Each proxy appends the address it
+// received the request from, so with trustedProxies proxies in front of the
+// service only the last trustedProxies entries are considered. Zero or less
+// trusts the whole header, which is only safe when every hop is ours.
+func ClientIPFromHeaders(headers http.Header, trustedProxies int) (string, error) {
+	var hops []string
+	for _, h := range headers.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(h, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	if len(hops) == 0 {
+		if real := strings.TrimSpace(headers.Get("X-Real-IP")); real != "" {
+			hops = []string{real}
+		}
+	}
+	if trustedProxies > 0 && len(hops) > trustedProxies {
+		hops = hops[len(hops)-trustedProxies:]
+	}
+
+	for _, hop := range hops {
+		if ip := parseHop(hop); ip != nil && isPublicIP(ip) {
+			return ip.String(), nil
+		}
+	}
+	return "", ErrNoClientIP
+}
+
+// parseHop accepts a bare address or one with a port, e.g. "[::1]:443".
+func parseHop(hop string) net.IP {
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		hop = host
+	}
+	return net.ParseIP(strings.Trim(hop, "[]"))
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		return !isReservedIPv4(ip4)
+	}
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
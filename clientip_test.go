@@ -0,0 +1,56 @@
+package iplocate
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestClientIPFromHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		xff     []string
+		realIP  string
+		trusted int
+		want    string
+	}{
+		{name: "single hop", xff: []string{"41.67.0.1"}, want: "41.67.0.1"},
+		{name: "multi hop", xff: []string{"41.67.0.1, 8.8.8.8, 1.1.1.1"}, want: "41.67.0.1"},
+		{name: "skips private hops", xff: []string{"10.0.0.5, 192.168.1.1, 41.67.0.1, 8.8.8.8"}, want: "41.67.0.1"},
+		{name: "repeated headers", xff: []string{"10.0.0.5", "41.67.0.1, 8.8.8.8"}, want: "41.67.0.1"},
+		{name: "ports", xff: []string{"41.67.0.1:5123, [2001:4860:4860::8888]:443"}, want: "41.67.0.1"},
+		{name: "ipv6", xff: []string{"fd00::1, 2001:4860:4860::8888"}, want: "2001:4860:4860::8888"},
+		// The client claimed 1.2.3.4; our two proxies saw 41.67.0.1 and
+		// 172.16.0.9.
+		{name: "spoofed prefix", xff: []string{"1.2.3.4, 41.67.0.1, 172.16.0.9"}, trusted: 2, want: "41.67.0.1"},
+		{name: "fewer hops than proxies", xff: []string{"41.67.0.1"}, trusted: 3, want: "41.67.0.1"},
+		{name: "real ip fallback", realIP: "41.67.0.1", want: "41.67.0.1"},
+		{name: "xff wins over real ip", xff: []string{"8.8.8.8"}, realIP: "41.67.0.1", want: "8.8.8.8"},
+		{name: "garbage is skipped", xff: []string{"unknown, 41.67.0.1"}, want: "41.67.0.1"},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		for _, v := range tt.xff {
+			h.Add("X-Forwarded-For", v)
+		}
+		if tt.realIP != "" {
+			h.Set("X-Real-IP", tt.realIP)
+		}
+		got, err := ClientIPFromHeaders(h, tt.trusted)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: ClientIPFromHeaders = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestClientIPFromHeaders_NoPublicIP(t *testing.T) {
+	for _, h := range []http.Header{
+		{},
+		{"X-Forwarded-For": {"10.0.0.1, 127.0.0.1"}},
+		{"X-Real-Ip": {"192.168.0.10"}},
+	} {
+		if got, err := ClientIPFromHeaders(h, 0); !errors.Is(err, ErrNoClientIP) {
+			t.Errorf("%v: got %q, %v; want ErrNoClientIP", h, got, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package iplocate
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"os"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"google.golang.org/protobuf/proto"
+)
+
+// EncryptionKeyEnv holds a raw 16, 24 or 32 byte AES key. When it is set,
+// Dial encrypts every payload with it.
+const EncryptionKeyEnv = "IPLOCATE_ENCRYPTION_KEY"
+
+const (
+	encryptedEncoding = "binary/encrypted"
+	metadataKeyID     = "encryption-key-id"
+)
+
+// KeyProvider supplies AES keys (16, 24 or 32 bytes). New payloads are
+// encrypted with CurrentKeyID; older payloads are decrypted with whatever
+// key ID they were written with, so keys can be rotated.
+type KeyProvider interface {
+	CurrentKeyID() string
+	KeyFor(id string) ([]byte, error)
+}
+
+type StaticKeyProvider struct {
+	ID     string
+	Secret []byte
+}
+
+func (s StaticKeyProvider) CurrentKeyID() string {
+	return s.ID
+}
+
+func (s StaticKeyProvider) KeyFor(id string) ([]byte, error) {
+	if id != s.ID {
+		return nil, fmt.Errorf("unknown encryption key %q", id)
+	}
+	return s.Secret, nil
+}
+
+// NewEncryptedDataConverter wraps the default converter so every payload
+// (workflow inputs, activity results, Data, ...) is AES-GCM encrypted
+// before it reaches Temporal's history.
+//
+// The Web UI and the temporal CLI only see ciphertext; to read payloads
+// there, run a codec server with the same keys and point the UI at it.
+func NewEncryptedDataConverter(keys KeyProvider) converter.DataConverter {
+	return converter.NewCodecDataConverter(converter.GetDefaultDataConverter(), &encryptionCodec{keys: keys})
+}
+
+// NewEncryptedClient dials Temporal with NewEncryptedDataConverter set. A
+// worker built on the returned client uses the same converter, so starters
+// and workers must share the key provider.
+func NewEncryptedClient(opts client.Options, keys KeyProvider) (client.Client, error) {
+	if _, err := newGCM(keys, keys.CurrentKeyID()); err != nil {
+		return nil, err
+	}
+	opts.DataConverter = NewEncryptedDataConverter(keys)
+	return client.Dial(opts)
+}
+
+// Dial connects like client.Dial, switching to NewEncryptedClient when
+// EncryptionKeyEnv is set.
+func Dial(opts client.Options) (client.Client, error) {
+	if key := os.Getenv(EncryptionKeyEnv); key != "" {
+		return NewEncryptedClient(opts, StaticKeyProvider{ID: "env", Secret: []byte(key)})
+	}
+	return client.Dial(opts)
+}
+
+type encryptionCodec struct {
+	keys KeyProvider
+}
+
+func newGCM(keys KeyProvider, id string) (cipher.AEAD, error) {
+	key, err := keys.KeyFor(id)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key %q: %w", id, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func (e *encryptionCodec) Encode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	id := e.keys.CurrentKeyID()
+	gcm, err := newGCM(e.keys, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		plain, err := proto.Marshal(p)
+		if err != nil {
+			return nil, err
+		}
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		result[i] = &commonpb.Payload{
+			Metadata: map[string][]byte{
+				converter.MetadataEncoding: []byte(encryptedEncoding),
+				metadataKeyID:              []byte(id),
+			},
+			Data: gcm.Seal(nonce, nonce, plain, nil),
+		}
+	}
+	return result, nil
+}
+
+func (e *encryptionCodec) Decode(payloads []*commonpb.Payload) ([]*commonpb.Payload, error) {
+	result := make([]*commonpb.Payload, len(payloads))
+	for i, p := range payloads {
+		if string(p.Metadata[converter.MetadataEncoding]) != encryptedEncoding {
+			result[i] = p
+			continue
+		}
+
+		gcm, err := newGCM(e.keys, string(p.Metadata[metadataKeyID]))
+		if err != nil {
+			return nil, err
+		}
+		if len(p.Data) < gcm.NonceSize() {
+			return nil, fmt.Errorf("encrypted payload too short")
+		}
+		nonce, sealed := p.Data[:gcm.NonceSize()], p.Data[gcm.NonceSize():]
+		plain, err := gcm.Open(nil, nonce, sealed, nil)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt payload: %w", err)
+		}
+
+		result[i] = &commonpb.Payload{}
+		if err := proto.Unmarshal(plain, result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
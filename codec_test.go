@@ -0,0 +1,46 @@
+package iplocate
+
+import (
+	"bytes"
+	"testing"
+
+	"go.temporal.io/sdk/converter"
+)
+
+func TestEncryptedDataConverter_RoundTrip(t *testing.T) {
+	keys := StaticKeyProvider{ID: "test", Secret: bytes.Repeat([]byte("k"), 32)}
+	dc := NewEncryptedDataConverter(keys)
+
+	in := Data{Result: "8.8.8.8", Location: "City: Ashburn", Zone: "America/New_York"}
+	payload, err := dc.ToPayload(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(payload.Metadata[converter.MetadataEncoding]); got != encryptedEncoding {
+		t.Errorf("encoding = %q, want %q", got, encryptedEncoding)
+	}
+	if bytes.Contains(payload.Data, []byte("Ashburn")) {
+		t.Error("payload contains plaintext location")
+	}
+
+	var out Data
+	if err := dc.FromPayload(payload, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestEncryptedDataConverter_WrongKey(t *testing.T) {
+	payload, err := NewEncryptedDataConverter(StaticKeyProvider{ID: "a", Secret: bytes.Repeat([]byte("a"), 32)}).ToPayload("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out string
+	other := NewEncryptedDataConverter(StaticKeyProvider{ID: "a", Secret: bytes.Repeat([]byte("b"), 32)})
+	if err := other.FromPayload(payload, &out); err == nil {
+		t.Fatal("expected decrypt error with the wrong key")
+	}
+}
@@ -0,0 +1,128 @@
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Names under which ProviderComparisonWorkflow looks up its two providers
+// in IPActivities.Providers.
+const (
+	PrimaryProvider   = "primary"
+	SecondaryProvider = "secondary"
+)
+
+// Application error types returned by ProviderLookup.
+const (
+	ErrUnknownProvider = "UnknownProvider"
+	ErrNoLocation      = "NoLocation"
+	ErrProviderAuth    = "ProviderAuth"
+)
+
+// ProviderResult is one provider's answer. When Available is false the
+// provider failed and Error says why; Details is then empty.
+type ProviderResult struct {
+	Provider  string
+	Available bool
+	Error     string
+	Details   LocationDetails
+}
+
+// ComparisonResult reports how far two providers agree on one IP. The
+// field lists and DistanceKm are only filled in when both are Available.
+type ComparisonResult struct {
+	IP              string
+	Primary         ProviderResult
+	Secondary       ProviderResult
+	MatchingFields  []string
+	DifferingFields []string
+	DistanceKm      float64
+}
+
+// ProviderLookup resolves ip with the provider registered under name.
+func (i *IPActivities) ProviderLookup(ctx context.Context, name, ip string) (LocationDetails, error) {
+	provider, ok := i.Providers[name]
+	if !ok {
+		return LocationDetails{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("no provider registered as %q", name), ErrUnknownProvider, nil)
+	}
+
+	details, err := provider.Lookup(ctx, ip)
+	switch {
+	case errors.Is(err, ErrLocationNotFound):
+		return LocationDetails{}, temporal.NewNonRetryableApplicationError(err.Error(), ErrNoLocation, err)
+	case errors.Is(err, ErrProviderUnauthorized):
+		return LocationDetails{}, temporal.NewNonRetryableApplicationError(err.Error(), ErrProviderAuth, err)
+	}
+	return details, err
+}
+
+// ProviderComparisonWorkflow looks ip up with PrimaryProvider and
+// SecondaryProvider concurrently and diffs the answers. A provider that
+// fails is reported as unavailable instead of failing the workflow.
+func ProviderComparisonWorkflow(ctx workflow.Context, ip string) (ComparisonResult, error) {
+	if net.ParseIP(ip) == nil {
+		return ComparisonResult{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("invalid ip %q", ip), ErrInvalidInput, nil)
+	}
+
+	// A provider that is down should not hold the comparison up for the
+	// full DefaultRetryPolicy schedule.
+	ao := ActivityConfig{MaximumAttempts: 3}.Options()
+	ctx = workflow.WithActivityOptions(ctx, ao)
+
+	primary := executeProviderLookup(ctx, PrimaryProvider, ip)
+	secondary := executeProviderLookup(ctx, SecondaryProvider, ip)
+
+	result := ComparisonResult{
+		IP:        ip,
+		Primary:   providerResult(ctx, PrimaryProvider, primary),
+		Secondary: providerResult(ctx, SecondaryProvider, secondary),
+	}
+	if result.Primary.Available && result.Secondary.Available {
+		diffDetails(&result)
+	}
+	return result, nil
+}
+
+func providerResult(ctx workflow.Context, name string, f workflow.Future) ProviderResult {
+	r := ProviderResult{Provider: name}
+	if err := f.Get(ctx, &r.Details); err != nil {
+		workflow.GetLogger(ctx).Warn("Provider unavailable", "provider", name, "error", err)
+		r.Error = err.Error()
+		return r
+	}
+	r.Available = true
+	return r
+}
+
+func diffDetails(r *ComparisonResult) {
+	a, b := r.Primary.Details, r.Secondary.Details
+	for _, f := range []struct {
+		name string
+		a, b string
+	}{
+		{"City", a.City, b.City},
+		{"Region", a.Region, b.Region},
+		{"Country", a.Country, b.Country},
+		{"CountryCode", a.CountryCode, b.CountryCode},
+		{"Timezone", a.Timezone, b.Timezone},
+	} {
+		// Providers such as ipinfo only give the country code; comparing
+		// their empty name would always report a difference.
+		if f.name == "Country" && (a.Country == "" || b.Country == "") && a.CountryCode != "" && b.CountryCode != "" {
+			continue
+		}
+		if f.a == f.b {
+			r.MatchingFields = append(r.MatchingFields, f.name)
+		} else {
+			r.DifferingFields = append(r.DifferingFields, f.name)
+		}
+	}
+	r.DistanceKm = haversineKm(a.Lat, a.Lon, b.Lat, b.Lon)
+}
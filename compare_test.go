@@ -0,0 +1,96 @@
+package iplocate
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestProviderComparisonWorkflow(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{
+		Providers: map[string]GeoProvider{
+			PrimaryProvider: NewStaticProvider(map[string]LocationDetails{
+				"8.8.8.8": {City: "Mountain View", Region: "California", Country: "United States", CountryCode: "US", Lat: 37.386, Lon: -122.0838},
+			}),
+			SecondaryProvider: NewStaticProvider(map[string]LocationDetails{
+				"8.8.8.8": {City: "San Francisco", Region: "California", Country: "United States", CountryCode: "US", Lat: 37.7749, Lon: -122.4194},
+			}),
+		},
+	})
+
+	env.ExecuteWorkflow(ProviderComparisonWorkflow, "8.8.8.8")
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	var got ComparisonResult
+	if err := env.GetWorkflowResult(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Primary.Available || !got.Secondary.Available {
+		t.Fatalf("providers unavailable: %+v", got)
+	}
+	if got.Primary.Details.City != "Mountain View" || got.Secondary.Details.City != "San Francisco" {
+		t.Errorf("cities = %q, %q", got.Primary.Details.City, got.Secondary.Details.City)
+	}
+	if want := []string{"City"}; !reflect.DeepEqual(got.DifferingFields, want) {
+		t.Errorf("DifferingFields = %v, want %v", got.DifferingFields, want)
+	}
+	if want := []string{"Region", "Country", "CountryCode", "Timezone"}; !reflect.DeepEqual(got.MatchingFields, want) {
+		t.Errorf("MatchingFields = %v, want %v", got.MatchingFields, want)
+	}
+	// Mountain View to San Francisco is about 53km.
+	if math.Abs(got.DistanceKm-53) > 5 {
+		t.Errorf("DistanceKm = %.1f, want ~53", got.DistanceKm)
+	}
+}
+
+func TestProviderComparisonWorkflow_ProviderUnavailable(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{
+		Providers: map[string]GeoProvider{
+			PrimaryProvider: NewStaticProvider(map[string]LocationDetails{
+				"8.8.8.8": {City: "Mountain View", Country: "United States"},
+			}),
+			// SecondaryProvider is not registered.
+		},
+	})
+
+	env.ExecuteWorkflow(ProviderComparisonWorkflow, "8.8.8.8")
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	var got ComparisonResult
+	if err := env.GetWorkflowResult(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Primary.Available || got.Primary.Details.City != "Mountain View" {
+		t.Errorf("primary = %+v", got.Primary)
+	}
+	if got.Secondary.Available || got.Secondary.Error == "" {
+		t.Errorf("secondary = %+v, want unavailable with an error", got.Secondary)
+	}
+	if got.MatchingFields != nil || got.DifferingFields != nil || got.DistanceKm != 0 {
+		t.Errorf("diff filled in with a provider missing: %+v", got)
+	}
+}
+
+func TestDiffDetails_CountryCodeOnly(t *testing.T) {
+	r := ComparisonResult{
+		Primary:   ProviderResult{Details: LocationDetails{City: "Sydney", Country: "Australia", CountryCode: "AU"}},
+		Secondary: ProviderResult{Details: LocationDetails{City: "Sydney", CountryCode: "AU"}},
+	}
+	diffDetails(&r)
+	if len(r.DifferingFields) != 0 {
+		t.Errorf("DifferingFields = %v, want none", r.DifferingFields)
+	}
+	if want := []string{"City", "Region", "CountryCode", "Timezone"}; !reflect.DeepEqual(r.MatchingFields, want) {
+		t.Errorf("MatchingFields = %v, want %v", r.MatchingFields, want)
+	}
+}
@@ -0,0 +1,107 @@
+package iplocate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ErrLowConfidence is the application error type for lookups where no
+// provider filled in every required field.
+const ErrLowConfidence = "LowConfidence"
+
+// CoordinatesField can be listed in MinFields to require a non-zero Lat or
+// Lon.
+const CoordinatesField = "Coordinates"
+
+// missingFields returns the names in minFields whose LocationDetails field
+// is empty in d. Names are LocationDetails string field names or
+// CoordinatesField, matched case-insensitively.
+func missingFields(d LocationDetails, minFields []string) ([]string, error) {
+	var missing []string
+	for _, name := range minFields {
+		var empty bool
+		switch strings.ToLower(name) {
+		case "city":
+			empty = d.City == ""
+		case "district":
+			empty = d.District == ""
+		case "region":
+			empty = d.Region == ""
+		case "country":
+			empty = d.Country == ""
+		case "countrycode":
+			empty = d.CountryCode == ""
+		case "continent":
+			empty = d.Continent == ""
+		case "continentcode":
+			empty = d.ContinentCode == ""
+		case "timezone":
+			empty = d.Timezone == ""
+		case "coordinates":
+			empty = d.Lat == 0 && d.Lon == 0
+		default:
+			return nil, fmt.Errorf("unknown location field %q", name)
+		}
+		if empty {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// locateWithFallback looks ip up with PrimaryProvider and, when that leaves
+// any of minFields empty, with SecondaryProvider. If neither fills them all
+// it fails with ErrLowConfidence. minFields must already be validated.
+func locateWithFallback(ctx workflow.Context, ip string, minFields []string) (LocationDetails, error) {
+	logger := workflow.GetLogger(ctx)
+
+	var details LocationDetails
+	err := executeProviderLookup(ctx, PrimaryProvider, ip).Get(ctx, &details)
+	if err != nil {
+		return LocationDetails{}, fmt.Errorf("failed to look up %s: %w", ip, err)
+	}
+	missing, _ := missingFields(details, minFields)
+	if len(missing) == 0 {
+		return details, nil
+	}
+	logger.Warn("Location below threshold, trying fallback provider", "ip", ip, "missing", missing)
+
+	var fallback LocationDetails
+	err = executeProviderLookup(ctx, SecondaryProvider, ip).Get(ctx, &fallback)
+	if err != nil {
+		logger.Warn("Fallback provider failed", "ip", ip, "error", err)
+	} else if m, _ := missingFields(fallback, minFields); len(m) == 0 {
+		return fallback, nil
+	}
+	return LocationDetails{}, temporal.NewNonRetryableApplicationError(
+		fmt.Sprintf("%s: no provider returned %s", ip, strings.Join(missing, ", ")), ErrLowConfidence, nil)
+}
+
+// lookupLocation is the location step of the lookup workflows. With no
+// minFields it is GetLocationInfo; otherwise it is locateWithFallback,
+// formatted the way GetLocationInfo formats its answer.
+func lookupLocation(ctx workflow.Context, ip string, minFields []string) (string, error) {
+	if len(minFields) == 0 {
+		var ipActivities *IPActivities
+		var location string
+		err := workflow.ExecuteActivity(ctx, ipActivities.GetLocationInfo, ip).Get(ctx, &location)
+		return location, err
+	}
+	d, err := locateWithFallback(ctx, ip, minFields)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("City: %s, Region: %s, Country: %s", d.City, d.Region, d.Country), nil
+}
+
+// isLowConfidence reports whether err is locateWithFallback's
+// ErrLowConfidence failure, which workflows return as is so callers can
+// match its type.
+func isLowConfidence(err error) bool {
+	var appErr *temporal.ApplicationError
+	return errors.As(err, &appErr) && appErr.Type() == ErrLowConfidence
+}
@@ -0,0 +1,90 @@
+package iplocate
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// DescribeQuery returns a WorkflowDescription for the versioned lookup
+// workflows.
+const DescribeQuery = "describe"
+
+type WorkflowDescription struct {
+	WorkflowType string
+	WorkflowID   string
+	RunID        string
+	Attempt      int32
+	TaskQueue    string
+	StartedAt    time.Time
+	// Versions maps each GetVersion change ID the run has reached so far to
+	// the version it took. Change IDs not reached yet are absent.
+	Versions map[string]workflow.Version
+}
+
+// describer records GetVersion results as the workflow runs, since query
+// handlers can't call GetVersion themselves.
+type describer struct {
+	desc WorkflowDescription
+}
+
+func registerDescribe(ctx workflow.Context) (*describer, error) {
+	info := workflow.GetInfo(ctx)
+	d := &describer{desc: WorkflowDescription{
+		WorkflowType: info.WorkflowType.Name,
+		WorkflowID:   info.WorkflowExecution.ID,
+		RunID:        info.WorkflowExecution.RunID,
+		Attempt:      info.Attempt,
+		TaskQueue:    info.TaskQueueName,
+		StartedAt:    info.WorkflowStartTime,
+		Versions:     make(map[string]workflow.Version),
+	}}
+	err := workflow.SetQueryHandler(ctx, DescribeQuery, func() (WorkflowDescription, error) {
+		desc := d.desc
+		desc.Versions = make(map[string]workflow.Version, len(d.desc.Versions))
+		for id, v := range d.desc.Versions {
+			desc.Versions[id] = v
+		}
+		return desc, nil
+	})
+	return d, err
+}
+
+func (d *describer) getVersion(ctx workflow.Context, changeID string, minSupported, maxSupported workflow.Version) workflow.Version {
+	v := workflow.GetVersion(ctx, changeID, minSupported, maxSupported)
+	d.desc.Versions[changeID] = v
+	return v
+}
+
+// RetryPolicyQuery returns the RetryPolicyInfo the lookup workflows run
+// their activities with.
+const RetryPolicyQuery = "retry-policy"
+
+// RetryPolicyInfo mirrors temporal.RetryPolicy. Zero values mean the
+// server defaults apply.
+type RetryPolicyInfo struct {
+	InitialInterval        time.Duration
+	BackoffCoefficient     float64
+	MaximumInterval        time.Duration
+	MaximumAttempts        int32
+	NonRetryableErrorTypes []string
+}
+
+// registerRetryPolicy captures a copy of policy at setup time, since the
+// activity options in ctx can't be read back from a query handler.
+func registerRetryPolicy(ctx workflow.Context, policy *temporal.RetryPolicy) error {
+	var info RetryPolicyInfo
+	if policy != nil {
+		info = RetryPolicyInfo{
+			InitialInterval:        policy.InitialInterval,
+			BackoffCoefficient:     policy.BackoffCoefficient,
+			MaximumInterval:        policy.MaximumInterval,
+			MaximumAttempts:        policy.MaximumAttempts,
+			NonRetryableErrorTypes: append([]string(nil), policy.NonRetryableErrorTypes...),
+		}
+	}
+	return workflow.SetQueryHandler(ctx, RetryPolicyQuery, func() (RetryPolicyInfo, error) {
+		return info, nil
+	})
+}
@@ -0,0 +1,95 @@
+package iplocate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestDescribeQuery(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.OnActivity("GetIP", mock.Anything).Return("8.8.8.8", nil)
+	env.OnActivity("RecordLookup", mock.Anything, "8.8.8.8", mock.Anything).Return("1-8.8.8.8", nil)
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("City: Ashburn", nil)
+	env.OnActivity("GetTimeZone", mock.Anything, "8.8.8.8").Return("America/New_York", nil)
+
+	// During the demo sleep only cancel-lookup, wait-activity-cancel,
+	// v2-input-ip and demo-sleep have been reached.
+	env.RegisterDelayedCallback(func() {
+		desc := queryDescribe(t, env)
+		want := map[string]workflow.Version{"cancel-lookup": 1, "wait-activity-cancel": 1, "v2-input-ip": 1, "demo-sleep": 1}
+		if !reflect.DeepEqual(desc.Versions, want) {
+			t.Errorf("versions during the sleep = %v, want %v", desc.Versions, want)
+		}
+	}, 10*time.Second)
+
+	env.ExecuteWorkflow(GetAddressFromIPV2, "", LookupOptions{})
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+
+	desc := queryDescribe(t, env)
+	if desc.WorkflowType != "GetAddressFromIPV2" || desc.WorkflowID != "default-test-workflow-id" {
+		t.Errorf("description = %+v", desc)
+	}
+	want := map[string]workflow.Version{"cancel-lookup": 1, "wait-activity-cancel": 1, "v2-input-ip": 1, "demo-sleep": 1, "optional-timezone": 1, "record-result": 1, "audit-record": 1}
+	for id, v := range want {
+		if desc.Versions[id] != v {
+			t.Errorf("Versions[%q] = %d, want %d", id, desc.Versions[id], v)
+		}
+	}
+	if len(desc.Versions) != len(want) {
+		t.Errorf("Versions = %v, want %v", desc.Versions, want)
+	}
+}
+
+func TestRetryPolicyQuery(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("City: Ashburn", nil)
+
+	env.ExecuteWorkflow(GetAddressFromIP, "", LookupOptions{IP: "8.8.8.8", DemoSleep: NoDemoSleep})
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+
+	encoded, err := env.QueryWorkflow(RetryPolicyQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got RetryPolicyInfo
+	if err := encoded.Get(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultRetryPolicy()
+	if got.InitialInterval != want.InitialInterval || got.BackoffCoefficient != want.BackoffCoefficient ||
+		got.MaximumInterval != want.MaximumInterval || got.MaximumAttempts != want.MaximumAttempts ||
+		!reflect.DeepEqual(got.NonRetryableErrorTypes, want.NonRetryableErrorTypes) {
+		t.Errorf("retry policy = %+v, want %+v", got, *want)
+	}
+}
+
+// queryDescribe reports failures with t.Error, not t.Fatal, because it also
+// runs inside delayed callbacks.
+func queryDescribe(t *testing.T, env *testsuite.TestWorkflowEnvironment) WorkflowDescription {
+	t.Helper()
+	encoded, err := env.QueryWorkflow(DescribeQuery)
+	if err != nil {
+		t.Errorf("describe query: %v", err)
+		return WorkflowDescription{}
+	}
+	var desc WorkflowDescription
+	if err := encoded.Get(&desc); err != nil {
+		t.Error(err)
+	}
+	return desc
+}
@@ -0,0 +1,79 @@
+package iplocate
+
+import (
+	"errors"
+	"fmt"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrorCategory says what kind of failure a LookupError is, so workflows
+// and metrics can branch on it without parsing messages.
+type ErrorCategory string
+
+const (
+	// CategoryNetwork covers failed requests and bodies cut short.
+	CategoryNetwork ErrorCategory = "Network"
+	// CategoryParse is a response that isn't the JSON we expect.
+	CategoryParse ErrorCategory = "Parse"
+	// CategoryProviderFail is the provider answering with an error of its own.
+	CategoryProviderFail ErrorCategory = "ProviderFail"
+	// CategoryRateLimit is an HTTP 429.
+	CategoryRateLimit ErrorCategory = "RateLimit"
+	// CategoryValidation is a lookup the provider will never answer, such as
+	// a reserved range or a malformed IP.
+	CategoryValidation ErrorCategory = "Validation"
+)
+
+// LookupError is the error every ip-api activity fails with. Activities
+// return it as the cause of an ApplicationError whose type is the category,
+// or ErrReservedRange / ErrInvalidQuery for those failures so that
+// DefaultRetryPolicy keeps refusing to retry them. The category is also
+// attached as the ApplicationError's details; use ErrorCategoryOf to read
+// it on either side of an activity call.
+type LookupError struct {
+	Category ErrorCategory
+	// Op is the step that failed, e.g. "HTTP GET" or "JSON unmarshal".
+	Op  string
+	Err error
+}
+
+func (e *LookupError) Error() string {
+	return fmt.Sprintf("%s error: %v", e.Op, e.Err)
+}
+
+func (e *LookupError) Unwrap() error {
+	return e.Err
+}
+
+// lookupError classifies err as category, unless something below already
+// did, and wraps it for the activity boundary.
+func lookupError(category ErrorCategory, op string, err error) error {
+	var le *LookupError
+	if errors.As(err, &le) {
+		return err
+	}
+	return newLookupError(&LookupError{Category: category, Op: op, Err: err}, string(category))
+}
+
+func newLookupError(le *LookupError, errType string) error {
+	return temporal.NewApplicationErrorWithCause(le.Error(), errType, le, le.Category)
+}
+
+// ErrorCategoryOf returns the category of a LookupError in err's chain, or
+// of the ApplicationError an activity turned it into. It returns "" for
+// errors that weren't classified.
+func ErrorCategoryOf(err error) ErrorCategory {
+	var le *LookupError
+	if errors.As(err, &le) {
+		return le.Category
+	}
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) && appErr.HasDetails() {
+		var category ErrorCategory
+		if appErr.Details(&category) == nil {
+			return category
+		}
+	}
+	return ""
+}
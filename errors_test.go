@@ -0,0 +1,75 @@
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestErrorCategoryOf_Activities(t *testing.T) {
+	url := "http://ip-api.com/json/8.8.8.8?fields=timezone"
+	for _, tc := range []struct {
+		name   string
+		getter HTTPGetter
+		want   ErrorCategory
+	}{
+		{"network", &stubGetter{}, CategoryNetwork},
+		{"parse", &stubGetter{bodies: map[string]string{url: `<html>`}}, CategoryParse},
+		{"provider", &stubGetter{bodies: map[string]string{url: `{"status":"fail","message":"SSL unavailable"}`}}, CategoryProviderFail},
+		{"rate limit", &statusGetter{status: http.StatusTooManyRequests}, CategoryRateLimit},
+		{"validation", &stubGetter{bodies: map[string]string{url: `{"status":"fail","message":"invalid query"}`}}, CategoryValidation},
+	} {
+		a := &IPActivities{HTTPClient: tc.getter}
+		_, err := a.GetTimeZone(context.Background(), "8.8.8.8")
+		if got := ErrorCategoryOf(err); got != tc.want {
+			t.Errorf("%s: category = %q, want %q (err: %v)", tc.name, got, tc.want, err)
+		}
+		var le *LookupError
+		if !errors.As(err, &le) {
+			t.Errorf("%s: %v is not a LookupError", tc.name, err)
+		}
+	}
+}
+
+func TestErrorCategoryOf_KeepsRetryTypes(t *testing.T) {
+	var appErr *temporal.ApplicationError
+	err := apiError("private range")
+	if !errors.As(err, &appErr) || appErr.Type() != ErrReservedRange {
+		t.Errorf("err = %v, want type %s", err, ErrReservedRange)
+	}
+	if got := ErrorCategoryOf(err); got != CategoryValidation {
+		t.Errorf("category = %q, want %q", got, CategoryValidation)
+	}
+}
+
+func TestErrorCategoryOf_InWorkflow(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{HTTPClient: &stubGetter{bodies: map[string]string{
+		"http://ip-api.com/json/8.8.8.8": `not json`,
+	}}})
+	env.RegisterWorkflowWithOptions(func(ctx workflow.Context) (ErrorCategory, error) {
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			StartToCloseTimeout: time.Minute,
+			RetryPolicy:         &temporal.RetryPolicy{MaximumAttempts: 1},
+		})
+		var ipActivities *IPActivities
+		err := workflow.ExecuteActivity(ctx, ipActivities.GetLocationInfo, "8.8.8.8").Get(ctx, nil)
+		return ErrorCategoryOf(err), nil
+	}, workflow.RegisterOptions{Name: "categorize"})
+
+	env.ExecuteWorkflow("categorize")
+	var got ErrorCategory
+	if err := env.GetWorkflowResult(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != CategoryParse {
+		t.Errorf("category seen by workflow = %q, want %q", got, CategoryParse)
+	}
+}
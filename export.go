@@ -0,0 +1,135 @@
+package iplocate
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ExportSignal asks HourlyReportWorkflow to write the checks of its current
+// run to a file. Send it an ExportRequest.
+const ExportSignal = "export"
+
+// Formats for ExportRequest.Format.
+const (
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatJSON   = "json"
+)
+
+// HistoryEntry is one check made by a monitor.
+type HistoryEntry struct {
+	At       time.Time
+	Location LocationDetails
+	// Error is why the lookup failed; empty when it succeeded.
+	Error string `json:",omitempty"`
+}
+
+// ExportRequest is the payload of ExportSignal.
+type ExportRequest struct {
+	// Dest is an absolute path on the worker that runs the export. It is
+	// created or truncated.
+	Dest string
+	// Format is ExportFormatNDJSON, the default, or ExportFormatJSON.
+	Format string
+}
+
+// Validate checks the request before a monitor acts on it. It does no
+// I/O, so it is safe to call from workflow code; whether Dest is usable is
+// only known to the worker that writes it.
+func (r ExportRequest) Validate() error {
+	if !filepath.IsAbs(r.Dest) {
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("export destination %q must be an absolute path", r.Dest), ErrInvalidInput, nil)
+	}
+	switch r.Format {
+	case "", ExportFormatNDJSON, ExportFormatJSON:
+		return nil
+	}
+	return temporal.NewNonRetryableApplicationError(
+		fmt.Sprintf("unknown export format %q, want %s or %s", r.Format, ExportFormatNDJSON, ExportFormatJSON), ErrInvalidInput, nil)
+}
+
+func validateExportDest(dest string) error {
+	if !filepath.IsAbs(dest) {
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("export destination %q must be an absolute path", dest), ErrInvalidInput, nil)
+	}
+	if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("export destination %s is a directory", dest), ErrInvalidInput, nil)
+	}
+	return nil
+}
+
+// ExportNDJSON writes entries to dest as newline-delimited JSON, one entry
+// per line in order, encoding each as it goes rather than the whole export
+// at once.
+func (i *IPActivities) ExportNDJSON(ctx context.Context, dest string, entries []HistoryEntry) error {
+	return writeEntries(dest, entries, false)
+}
+
+// ExportJSON writes entries to dest as a single JSON array.
+func (i *IPActivities) ExportJSON(ctx context.Context, dest string, entries []HistoryEntry) error {
+	return writeEntries(dest, entries, true)
+}
+
+func writeEntries(dest string, entries []HistoryEntry, array bool) error {
+	if err := validateExportDest(dest); err != nil {
+		return err
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if array {
+		w.WriteByte('[')
+	}
+	for k, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("marshal entry %d: %w", k, err)
+		}
+		if array && k > 0 {
+			w.WriteByte(',')
+		}
+		w.Write(line)
+		if !array {
+			w.WriteByte('\n')
+		}
+	}
+	if array {
+		w.WriteString("]\n")
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// exportHistory runs the export req asks for. A bad request or a failed
+// export is logged rather than failing the monitor.
+func exportHistory(ctx workflow.Context, req ExportRequest, history []HistoryEntry) {
+	logger := workflow.GetLogger(ctx)
+	if err := req.Validate(); err != nil {
+		logger.Warn("Ignoring export request", "error", err)
+		return
+	}
+	var ipActivities *IPActivities
+	export := ipActivities.ExportNDJSON
+	if req.Format == ExportFormatJSON {
+		export = ipActivities.ExportJSON
+	}
+	if err := workflow.ExecuteActivity(ctx, export, req.Dest, history).Get(ctx, nil); err != nil {
+		logger.Warn("Export failed", "dest", req.Dest, "error", err)
+	}
+}
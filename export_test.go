@@ -0,0 +1,108 @@
+package iplocate
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+func exportEntries() []HistoryEntry {
+	at := time.Date(2026, 10, 15, 9, 50, 0, 0, time.UTC)
+	return []HistoryEntry{
+		{At: at, Location: LocationDetails{IP: "8.8.8.8", CountryCode: "US"}},
+		{At: at.Add(5 * time.Minute), Error: "provider down"},
+		{At: at.Add(10 * time.Minute), Location: LocationDetails{IP: "8.8.8.8", CountryCode: "SD"}},
+	}
+}
+
+func TestExportNDJSON(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "history.ndjson")
+	want := exportEntries()
+	if err := (&IPActivities{}).ExportNDJSON(context.Background(), dest, want); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if !json.Valid(scanner.Bytes()) {
+			t.Fatalf("line %d is not valid JSON: %s", len(got)+1, scanner.Text())
+		}
+		var e HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(got), len(want))
+	}
+	for k := range want {
+		if !got[k].At.Equal(want[k].At) || got[k].Location != want[k].Location || got[k].Error != want[k].Error {
+			t.Errorf("line %d = %+v, want %+v", k+1, got[k], want[k])
+		}
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "history.json")
+	if err := (&IPActivities{}).ExportJSON(context.Background(), dest, exportEntries()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []HistoryEntry
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("not a JSON array: %v", err)
+	}
+	if len(got) != 3 || got[1].Error != "provider down" {
+		t.Errorf("entries = %+v", got)
+	}
+}
+
+func TestExportNDJSON_RejectsBadDest(t *testing.T) {
+	for _, dest := range []string{"relative.ndjson", t.TempDir()} {
+		err := (&IPActivities{}).ExportNDJSON(context.Background(), dest, exportEntries())
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) || appErr.Type() != ErrInvalidInput {
+			t.Errorf("dest %q: err = %v, want %s", dest, err, ErrInvalidInput)
+		}
+	}
+}
+
+func TestExportRequest_Validate(t *testing.T) {
+	for _, req := range []ExportRequest{
+		{Dest: "/tmp/h.ndjson"},
+		{Dest: "/tmp/h.ndjson", Format: ExportFormatNDJSON},
+		{Dest: "/tmp/h.json", Format: ExportFormatJSON},
+	} {
+		if err := req.Validate(); err != nil {
+			t.Errorf("%+v: %v", req, err)
+		}
+	}
+	for _, req := range []ExportRequest{
+		{Dest: "h.ndjson"},
+		{Dest: "/tmp/h.csv", Format: "csv"},
+	} {
+		if err := req.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", req)
+		}
+	}
+}
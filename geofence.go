@@ -0,0 +1,140 @@
+package iplocate
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// PointInPolygon reports whether lat, lon lies inside polygon, given as
+// [lat, lon] vertices in order. Points on an edge or vertex count as
+// inside. Polygons with fewer than three vertices contain nothing. It is
+// pure, so it is safe to call from workflow code.
+func PointInPolygon(lat, lon float64, polygon [][2]float64) bool {
+	n := len(polygon)
+	if n < 3 {
+		return false
+	}
+	inside := false
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		latI, lonI := polygon[i][0], polygon[i][1]
+		latJ, lonJ := polygon[j][0], polygon[j][1]
+		if onSegment(lat, lon, latI, lonI, latJ, lonJ) {
+			return true
+		}
+		// Cast a ray towards increasing longitude and count the edges it
+		// crosses.
+		if (latI > lat) != (latJ > lat) && lon < (lonJ-lonI)*(lat-latI)/(latJ-latI)+lonI {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func onSegment(lat, lon, lat1, lon1, lat2, lon2 float64) bool {
+	cross := (lat2-lat1)*(lon-lon1) - (lon2-lon1)*(lat-lat1)
+	if math.Abs(cross) > 1e-9 {
+		return false
+	}
+	return lat >= math.Min(lat1, lat2) && lat <= math.Max(lat1, lat2) &&
+		lon >= math.Min(lon1, lon2) && lon <= math.Max(lon1, lon2)
+}
+
+// BoundingBox returns the smallest box holding every location with
+// coordinates, treating 0,0 as none like CoordinatesField does. ok is false
+// when no location has coordinates. It is pure, so it is safe to call from
+// workflow code.
+//
+// Longitudes are compared as plain numbers, so a set that straddles the
+// antimeridian (say Fiji and Samoa) gets a box spanning nearly the whole
+// globe rather than the narrow one across 180°.
+func BoundingBox(locations []LocationDetails) (minLat, minLon, maxLat, maxLon float64, ok bool) {
+	for _, l := range locations {
+		if l.Lat == 0 && l.Lon == 0 {
+			continue
+		}
+		if !ok {
+			minLat, minLon, maxLat, maxLon, ok = l.Lat, l.Lon, l.Lat, l.Lon, true
+			continue
+		}
+		minLat = math.Min(minLat, l.Lat)
+		minLon = math.Min(minLon, l.Lon)
+		maxLat = math.Max(maxLat, l.Lat)
+		maxLon = math.Max(maxLon, l.Lon)
+	}
+	return minLat, minLon, maxLat, maxLon, ok
+}
+
+// GeofenceEvent is one crossing of the geofence.
+type GeofenceEvent struct {
+	Entered  bool
+	At       time.Time
+	Location LocationDetails
+}
+
+// GeofenceWorkflow looks ip up with PrimaryProvider checks times, every
+// interval (less Backoff's jitter), and logs an alert whenever it enters or
+// leaves polygon. The first lookup with coordinates sets the starting side
+// without an alert. A failed lookup, or one without coordinates, is logged
+// and skipped until the next check. It returns the crossings it saw.
+func GeofenceWorkflow(ctx workflow.Context, ip string, polygon [][2]float64, interval time.Duration, checks int) ([]GeofenceEvent, error) {
+	switch {
+	case net.ParseIP(ip) == nil:
+		return nil, temporal.NewNonRetryableApplicationError(fmt.Sprintf("invalid ip %q", ip), ErrInvalidInput, nil)
+	case len(polygon) < 3:
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("polygon needs at least 3 vertices, got %d", len(polygon)), ErrInvalidInput, nil)
+	case interval <= 0 || checks <= 0:
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("interval and checks must be positive, got %s and %d", interval, checks), ErrInvalidInput, nil)
+	}
+
+	ao := ActivityConfig{}.Options()
+	ctx = workflow.WithActivityOptions(ctx, ao)
+	logger := workflow.GetLogger(ctx)
+
+	// Runs started before this change failed on the first failed lookup.
+	skipFailures := workflow.GetVersion(ctx, "geofence-skip-failures", workflow.DefaultVersion, 1) == 1
+
+	var events []GeofenceEvent
+	var wasInside, known bool
+	for check := 1; check <= checks; check++ {
+		if check > 1 {
+			if err := workflow.Sleep(ctx, Backoff(ctx, check, interval, interval)); err != nil {
+				return events, err
+			}
+		}
+
+		var details LocationDetails
+		err := executeProviderLookup(ctx, PrimaryProvider, ip).Get(ctx, &details)
+		if err != nil {
+			if !skipFailures {
+				return events, fmt.Errorf("failed to look up %s: %w", ip, err)
+			}
+			logger.Warn("Geofence lookup failed, waiting for the next check", "ip", ip, "check", check, "error", err)
+			continue
+		}
+		// 0,0 means the provider had no coordinates, not a point in the
+		// Gulf of Guinea that a fence might contain.
+		if details.Lat == 0 && details.Lon == 0 {
+			logger.Warn("Geofence lookup has no coordinates, waiting for the next check", "ip", ip, "check", check)
+			continue
+		}
+
+		inside := PointInPolygon(details.Lat, details.Lon, polygon)
+		if known && inside != wasInside {
+			action := "left"
+			if inside {
+				action = "entered"
+			}
+			logger.Warn("ALERT: IP "+action+" geofence", "ip", ip, "lat", details.Lat, "lon", details.Lon)
+			events = append(events, GeofenceEvent{Entered: inside, At: workflow.Now(ctx), Location: details})
+		}
+		wasInside, known = inside, true
+	}
+	return events, nil
+}
@@ -0,0 +1,112 @@
+package iplocate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// khartoum is a rough box around central Khartoum.
+var khartoum = [][2]float64{{15.50, 32.45}, {15.50, 32.60}, {15.65, 32.60}, {15.65, 32.45}}
+
+func TestPointInPolygon(t *testing.T) {
+	triangle := [][2]float64{{0, 0}, {0, 10}, {10, 0}}
+	tests := []struct {
+		name     string
+		lat, lon float64
+		polygon  [][2]float64
+		want     bool
+	}{
+		{"inside", 15.55, 32.53, khartoum, true},
+		{"outside", 19.61, 37.22, khartoum, false},
+		{"on an edge", 15.50, 32.50, khartoum, true},
+		{"on a vertex", 15.65, 32.60, khartoum, true},
+		{"on the hypotenuse", 5, 5, triangle, true},
+		{"beyond the hypotenuse", 6, 6, triangle, false},
+		{"too few vertices", 0, 0, [][2]float64{{0, 0}, {1, 1}}, false},
+		{"empty", 0, 0, nil, false},
+		{"collinear, off the line", 1, 0, [][2]float64{{0, 0}, {1, 1}, {2, 2}}, false},
+	}
+	for _, tt := range tests {
+		if got := PointInPolygon(tt.lat, tt.lon, tt.polygon); got != tt.want {
+			t.Errorf("%s: PointInPolygon(%v, %v) = %v, want %v", tt.name, tt.lat, tt.lon, got, tt.want)
+		}
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	locations := []LocationDetails{
+		{City: "Khartoum", Lat: 15.5, Lon: 32.56},
+		{City: "Cape Town", Lat: -33.92, Lon: 18.42},
+		{City: "Unknown"},
+		{City: "Oslo", Lat: 59.91, Lon: 10.75},
+		{City: "Lima", Lat: -12.05, Lon: -77.04},
+	}
+	minLat, minLon, maxLat, maxLon, ok := BoundingBox(locations)
+	if !ok || minLat != -33.92 || minLon != -77.04 || maxLat != 59.91 || maxLon != 32.56 {
+		t.Errorf("BoundingBox = %v %v %v %v %v, want -33.92 -77.04 59.91 32.56 true", minLat, minLon, maxLat, maxLon, ok)
+	}
+
+	if _, _, _, _, ok := BoundingBox([]LocationDetails{{City: "Unknown"}}); ok {
+		t.Error("ok = true with no coordinates")
+	}
+}
+
+func TestGeofenceWorkflow(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	port := LocationDetails{IP: "41.67.0.1", City: "Port Sudan", Lat: 19.61, Lon: 37.22}
+	capital := LocationDetails{IP: "41.67.0.1", City: "Khartoum", Lat: 15.55, Lon: 32.53}
+	for _, d := range []LocationDetails{port, capital, capital, port} {
+		env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "41.67.0.1").Return(d, nil).Once()
+	}
+
+	env.ExecuteWorkflow(GeofenceWorkflow, "41.67.0.1", khartoum, time.Minute, 4)
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	var events []GeofenceEvent
+	if err := env.GetWorkflowResult(&events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || !events[0].Entered || events[0].Location.City != "Khartoum" ||
+		events[1].Entered || events[1].Location.City != "Port Sudan" {
+		t.Errorf("events = %+v, want an entry then an exit", events)
+	}
+	env.AssertExpectations(t)
+}
+
+func TestGeofenceWorkflow_SkipsFailedAndUnplacedLookups(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	// The fence holds 0,0, so treating a lookup without coordinates as a
+	// point would look like an entry.
+	nullIsland := [][2]float64{{-10, -10}, {-10, 10}, {10, 10}, {10, -10}}
+	outside := LocationDetails{IP: "41.67.0.1", City: "Khartoum", Lat: 15.55, Lon: 32.53}
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "41.67.0.1").Return(LocationDetails{IP: "41.67.0.1"}, nil).Once()
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "41.67.0.1").Return(outside, nil).Once()
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "41.67.0.1").
+		Return(LocationDetails{}, temporal.NewNonRetryableApplicationError("provider down", "Unavailable", nil)).Once()
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "41.67.0.1").Return(LocationDetails{IP: "41.67.0.1"}, nil).Once()
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "41.67.0.1").Return(outside, nil).Once()
+
+	env.ExecuteWorkflow(GeofenceWorkflow, "41.67.0.1", nullIsland, time.Minute, 5)
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	var events []GeofenceEvent
+	if err := env.GetWorkflowResult(&events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want none", events)
+	}
+	env.AssertExpectations(t)
+}
@@ -0,0 +1,189 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Queries answered by HourlyReportWorkflow with a HourlySummary.
+const (
+	CurrentHourQuery  = "current-hour"
+	PreviousHourQuery = "previous-hour"
+)
+
+// HourlyReportSubject is where EmitHourlySummary publishes summaries when
+// IPActivities.Publisher is set.
+const HourlyReportSubject = "iplocate.hourly"
+
+// hourlyCheckInterval is how often HourlyReportWorkflow looks the IP up.
+const hourlyCheckInterval = 5 * time.Minute
+
+// HourlySummary covers the checks of one IP in the hour from HourStart.
+type HourlySummary struct {
+	IP        string
+	HourStart time.Time
+	Checks    int
+	Errors    int
+	// Countries counts successful checks by country code.
+	Countries map[string]int
+	// DominantCountry is the most frequent of Countries, ties going to the
+	// code that sorts first.
+	DominantCountry string
+}
+
+func (s *HourlySummary) add(countryCode string) {
+	if s.Countries == nil {
+		s.Countries = make(map[string]int)
+	}
+	s.Countries[countryCode]++
+	n := s.Countries[countryCode]
+	top := s.Countries[s.DominantCountry]
+	if s.DominantCountry == "" || n > top || (n == top && countryCode < s.DominantCountry) {
+		s.DominantCountry = countryCode
+	}
+}
+
+// EmitHourlySummary logs summary and publishes it as JSON to
+// HourlyReportSubject.
+func (i *IPActivities) EmitHourlySummary(ctx context.Context, summary HourlySummary) error {
+	activity.GetLogger(ctx).Info("hourly report", "summary", summary)
+	if i.Publisher == nil {
+		return nil
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("marshal summary: %w", err)
+	}
+	if err := i.Publisher.Publish(HourlyReportSubject, data); err != nil {
+		return fmt.Errorf("publish to %s: %w", HourlyReportSubject, err)
+	}
+	return nil
+}
+
+// HourlyReportWorkflow looks ip up with PrimaryProvider every five minutes,
+// passing each successful check to WriteTimeSeries, and on each hour
+// boundary emits a HourlySummary of the hour just ended through
+// EmitHourlySummary. It continues as new every 24 hours, carrying the last
+// summary along as previous; start it with nil.
+//
+// An ExportSignal writes the checks of the current run, at most a day's
+// worth, with ExportNDJSON or ExportJSON.
+func HourlyReportWorkflow(ctx workflow.Context, ip string, previous *HourlySummary) error {
+	if net.ParseIP(ip) == nil {
+		return temporal.NewNonRetryableApplicationError(fmt.Sprintf("invalid ip %q", ip), ErrInvalidInput, nil)
+	}
+
+	ao := ActivityConfig{MaximumAttempts: 3}.Options()
+	var ipActivities *IPActivities
+	ctx = workflow.WithActivityOptions(ctx, ao)
+	logger := workflow.GetLogger(ctx)
+
+	// Windows are aligned to the clock hour, which workflow.Now keeps
+	// deterministic on replay.
+	current := HourlySummary{IP: ip, HourStart: workflow.Now(ctx).Truncate(time.Hour)}
+	if err := workflow.SetQueryHandler(ctx, CurrentHourQuery, func() (HourlySummary, error) {
+		return current, nil
+	}); err != nil {
+		return err
+	}
+	if err := workflow.SetQueryHandler(ctx, PreviousHourQuery, func() (HourlySummary, error) {
+		if previous == nil {
+			return HourlySummary{}, nil
+		}
+		return *previous, nil
+	}); err != nil {
+		return err
+	}
+
+	timeSeries := workflow.GetVersion(ctx, "timeseries-sink", workflow.DefaultVersion, 1) == 1
+
+	// Runs started before this change left export signals unread.
+	var exportCh workflow.ReceiveChannel
+	if workflow.GetVersion(ctx, "export-signal", workflow.DefaultVersion, 1) == 1 {
+		exportCh = workflow.GetSignalChannel(ctx, ExportSignal)
+	}
+	var history []HistoryEntry
+
+	for hours := 0; hours < 24; {
+		var details LocationDetails
+		err := executeProviderLookup(ctx, PrimaryProvider, ip).Get(ctx, &details)
+		current.Checks++
+		entry := HistoryEntry{At: workflow.Now(ctx), Location: details}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		history = append(history, entry)
+		if err != nil {
+			current.Errors++
+			logger.Warn("Hourly check failed", "ip", ip, "error", err)
+		} else {
+			current.add(details.CountryCode)
+			if timeSeries {
+				// A sink outage must not cost the check.
+				err := workflow.ExecuteActivity(ctx, ipActivities.WriteTimeSeries, ip, details, workflow.Now(ctx)).Get(ctx, nil)
+				if err != nil {
+					logger.Warn("Failed to write time series point", "ip", ip, "error", err)
+				}
+			}
+		}
+
+		boundary := current.HourStart.Add(time.Hour)
+		wait := hourlyCheckInterval
+		if untilBoundary := boundary.Sub(workflow.Now(ctx)); untilBoundary < wait {
+			wait = untilBoundary
+		}
+		if wait > 0 {
+			if err := sleepServingExports(ctx, wait, exportCh, history); err != nil {
+				return err
+			}
+		}
+		if workflow.Now(ctx).Before(boundary) {
+			continue
+		}
+
+		summary := current
+		if err := workflow.ExecuteActivity(ctx, ipActivities.EmitHourlySummary, summary).Get(ctx, nil); err != nil {
+			logger.Warn("Failed to emit hourly summary", "ip", ip, "error", err)
+		}
+		previous = &summary
+		current = HourlySummary{IP: ip, HourStart: workflow.Now(ctx).Truncate(time.Hour)}
+		hours++
+	}
+	// Signals still queued would be lost with this run.
+	if exportCh != nil {
+		var req ExportRequest
+		for exportCh.ReceiveAsync(&req) {
+			exportHistory(ctx, req, history)
+		}
+	}
+	return workflow.NewContinueAsNewError(ctx, HourlyReportWorkflow, ip, previous)
+}
+
+// sleepServingExports waits d, running the exports requested on exportCh
+// meanwhile. A nil exportCh makes it a plain timer.
+func sleepServingExports(ctx workflow.Context, d time.Duration, exportCh workflow.ReceiveChannel, history []HistoryEntry) error {
+	var fired bool
+	var err error
+	sel := workflow.NewSelector(ctx)
+	sel.AddFuture(workflow.NewTimer(ctx, d), func(f workflow.Future) {
+		fired, err = true, f.Get(ctx, nil)
+	})
+	if exportCh != nil {
+		sel.AddReceive(exportCh, func(c workflow.ReceiveChannel, _ bool) {
+			var req ExportRequest
+			c.Receive(ctx, &req)
+			exportHistory(ctx, req, history)
+		})
+	}
+	for !fired {
+		sel.Select(ctx)
+	}
+	return err
+}
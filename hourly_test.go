@@ -0,0 +1,138 @@
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestHourlyReportWorkflow(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+	start := time.Date(2026, 10, 15, 9, 50, 0, 0, time.UTC)
+	env.SetStartTime(start)
+
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "8.8.8.8").
+		Return(LocationDetails{CountryCode: "SD"}, nil).Once()
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "8.8.8.8").
+		Return(LocationDetails{}, temporal.NewNonRetryableApplicationError("down", "Unavailable", nil)).Once()
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "8.8.8.8").
+		Return(LocationDetails{CountryCode: "US"}, nil)
+
+	var emitted []HourlySummary
+	env.OnActivity("EmitHourlySummary", mock.Anything, mock.Anything).Return(func(_ context.Context, s HourlySummary) error {
+		emitted = append(emitted, s)
+		return nil
+	})
+
+	// 10:12 is past the first boundary and after three checks in the new
+	// hour (10:00, 10:05, 10:10).
+	env.RegisterDelayedCallback(func() {
+		current := queryHourly(t, env, CurrentHourQuery)
+		if current.HourStart != start.Truncate(time.Hour).Add(time.Hour) || current.Checks != 3 || current.DominantCountry != "US" {
+			t.Errorf("current hour = %+v", current)
+		}
+		previous := queryHourly(t, env, PreviousHourQuery)
+		if previous.HourStart != start.Truncate(time.Hour) || previous.Checks != 2 || previous.Errors != 1 || previous.DominantCountry != "SD" {
+			t.Errorf("previous hour = %+v", previous)
+		}
+		env.CancelWorkflow()
+	}, 22*time.Minute)
+
+	env.ExecuteWorkflow(HourlyReportWorkflow, "8.8.8.8", (*HourlySummary)(nil))
+
+	if len(emitted) != 1 || emitted[0].Checks != 2 || emitted[0].Countries["SD"] != 1 {
+		t.Errorf("emitted = %+v, want one summary of the 9:00 hour", emitted)
+	}
+}
+
+func TestHourlyReportWorkflow_ContinuesAsNewDaily(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+	start := time.Date(2026, 10, 15, 9, 50, 0, 0, time.UTC)
+	env.SetStartTime(start)
+
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "8.8.8.8").Return(LocationDetails{CountryCode: "SD"}, nil)
+	env.OnActivity("EmitHourlySummary", mock.Anything, mock.Anything).Return(nil).Times(24)
+
+	env.ExecuteWorkflow(HourlyReportWorkflow, "8.8.8.8", (*HourlySummary)(nil))
+
+	var can *workflow.ContinueAsNewError
+	if err := env.GetWorkflowError(); !errors.As(err, &can) {
+		t.Fatalf("err = %v, want continue-as-new", err)
+	}
+	if elapsed := env.Now().Sub(start); elapsed != 23*time.Hour+10*time.Minute {
+		t.Errorf("ran for %s, want until the 24th boundary", elapsed)
+	}
+	env.AssertExpectations(t)
+}
+
+func TestHourlyReportWorkflow_Export(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+	start := time.Date(2026, 10, 15, 9, 50, 0, 0, time.UTC)
+	env.SetStartTime(start)
+
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "8.8.8.8").
+		Return(LocationDetails{}, temporal.NewNonRetryableApplicationError("down", "Unavailable", nil)).Once()
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "8.8.8.8").
+		Return(LocationDetails{CountryCode: "SD"}, nil)
+	env.OnActivity("EmitHourlySummary", mock.Anything, mock.Anything).Return(nil)
+
+	var exported []HistoryEntry
+	env.OnActivity("ExportNDJSON", mock.Anything, "/var/lib/iplocate/8.8.8.8.ndjson", mock.Anything).
+		Return(func(_ context.Context, _ string, entries []HistoryEntry) error {
+			exported = entries
+			return nil
+		}).Once()
+
+	// By 10:12 the checks at 9:50, 9:55, 10:00, 10:05 and 10:10 are done.
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(ExportSignal, ExportRequest{Dest: "/var/lib/iplocate/8.8.8.8.ndjson"})
+	}, 22*time.Minute)
+	// A bad request is logged and ignored, not run.
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(ExportSignal, ExportRequest{Dest: "relative.ndjson"})
+	}, 23*time.Minute)
+	env.RegisterDelayedCallback(env.CancelWorkflow, 24*time.Minute)
+
+	env.ExecuteWorkflow(HourlyReportWorkflow, "8.8.8.8", (*HourlySummary)(nil))
+
+	if len(exported) != 5 {
+		t.Fatalf("exported %d entries, want 5: %+v", len(exported), exported)
+	}
+	if exported[0].Error == "" || !exported[0].At.Equal(start) {
+		t.Errorf("first entry = %+v, want the failed 9:50 check", exported[0])
+	}
+	for k, e := range exported[1:] {
+		if e.Error != "" || e.Location.CountryCode != "SD" || !e.At.Equal(start.Add(time.Duration(k+1)*hourlyCheckInterval)) {
+			t.Errorf("entry %d = %+v", k+1, e)
+		}
+	}
+	env.AssertExpectations(t)
+}
+
+// queryHourly reports failures with t.Error because it runs inside a
+// delayed callback.
+func queryHourly(t *testing.T, env *testsuite.TestWorkflowEnvironment, query string) HourlySummary {
+	t.Helper()
+	encoded, err := env.QueryWorkflow(query)
+	if err != nil {
+		t.Errorf("%s query: %v", query, err)
+		return HourlySummary{}
+	}
+	var s HourlySummary
+	if err := encoded.Get(&s); err != nil {
+		t.Error(err)
+	}
+	return s
+}
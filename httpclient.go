@@ -0,0 +1,96 @@
+package iplocate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// HTTPConfig configures NewHTTPClient. Zero fields take the defaults below.
+type HTTPConfig struct {
+	// Timeout bounds a whole request, including reading the body.
+	Timeout time.Duration
+	// MaxIdleConns and MaxIdleConnsPerHost limit the connection pool.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// ProxyURL, when set, is used for every request. Otherwise the proxy
+	// comes from HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+	ProxyURL *url.URL
+}
+
+const (
+	DefaultHTTPTimeout         = 15 * time.Second
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 10
+)
+
+// MaxResponseBytesEnv overrides MaxResponseBytes on the worker.
+const MaxResponseBytesEnv = "MAX_RESPONSE_BYTES"
+
+// MaxResponseBytes caps how much of a provider response is read, so a
+// misbehaving provider can't exhaust the worker's memory. The worker sets
+// it from MaxResponseBytesEnv before it starts.
+var MaxResponseBytes int64 = 1 << 20
+
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// readBody reads r up to MaxResponseBytes, failing with ErrResponseTooLarge
+// rather than returning a truncated body. The error is non-retryable: a
+// retry would only download the same response again.
+func readBody(r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, MaxResponseBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > MaxResponseBytes {
+		le := &LookupError{
+			Category: CategoryProviderFail,
+			Op:       "read body",
+			Err:      fmt.Errorf("%w: over %d bytes", ErrResponseTooLarge, MaxResponseBytes),
+		}
+		return nil, temporal.NewNonRetryableApplicationError(le.Error(), string(le.Category), le, le.Category)
+	}
+	return body, nil
+}
+
+// NewHTTPClient builds a client for IPActivities.HTTPClient and the
+// providers. Unlike http.DefaultClient it has a timeout.
+func NewHTTPClient(cfg HTTPConfig) *http.Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultHTTPTimeout
+	}
+	if cfg.MaxIdleConns <= 0 {
+		cfg.MaxIdleConns = defaultMaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost <= 0 {
+		cfg.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if cfg.ProxyURL != nil {
+		proxy = http.ProxyURL(cfg.ProxyURL)
+	}
+
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			Proxy: proxy,
+			DialContext: (&net.Dialer{
+				Timeout:   5 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          cfg.MaxIdleConns,
+			MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
+}
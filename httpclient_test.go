@@ -0,0 +1,60 @@
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	c := NewHTTPClient(HTTPConfig{})
+	if c.Timeout != DefaultHTTPTimeout {
+		t.Errorf("Timeout = %s, want %s", c.Timeout, DefaultHTTPTimeout)
+	}
+	tr := c.Transport.(*http.Transport)
+	if tr.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost {
+		t.Errorf("MaxIdleConnsPerHost = %d", tr.MaxIdleConnsPerHost)
+	}
+
+	proxy, _ := url.Parse("http://proxy.corp.example:3128")
+	c = NewHTTPClient(HTTPConfig{ProxyURL: proxy})
+	req, _ := http.NewRequest("GET", "http://ip-api.com/json/8.8.8.8", nil)
+	got, err := c.Transport.(*http.Transport).Proxy(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.String() != proxy.String() {
+		t.Errorf("proxy = %v, want %v", got, proxy)
+	}
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	old := MaxResponseBytes
+	t.Cleanup(func() { MaxResponseBytes = old })
+	MaxResponseBytes = 64
+
+	const url = "http://ip-api.com/json/8.8.8.8?fields=timezone"
+	a := &IPActivities{HTTPClient: &stubGetter{bodies: map[string]string{
+		url: `{"status":"success","timezone":"` + strings.Repeat("x", 100) + `"}`,
+	}}}
+	_, err := a.GetTimeZone(context.Background(), "8.8.8.8")
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("err = %v, want ErrResponseTooLarge", err)
+	}
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || !appErr.NonRetryable() {
+		t.Errorf("err = %v, want a non-retryable ApplicationError", err)
+	}
+
+	body := `{"status":"success","timezone":"Africa/Khartoum"}`
+	MaxResponseBytes = int64(len(body))
+	a.HTTPClient = &stubGetter{bodies: map[string]string{url: body}}
+	if tz, err := a.GetTimeZone(context.Background(), "8.8.8.8"); err != nil || tz != "Africa/Khartoum" {
+		t.Errorf("at the limit: %q, %v", tz, err)
+	}
+}
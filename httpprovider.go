@@ -0,0 +1,175 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FieldMapping names the top-level JSON keys a provider uses for each
+// LocationDetails field. Empty keys are skipped. Status and Message, when
+// set, describe in-band failures like ip-api's {"status":"fail"}; Success
+// names a boolean key instead, like ipwho.is's {"success":false}.
+type FieldMapping struct {
+	City          string
+	District      string
+	Region        string
+	Country       string
+	CountryCode   string
+	Continent     string
+	ContinentCode string
+	Timezone      string
+	Lat           string
+	Lon           string
+	Status        string
+	Success       string
+	Message       string
+}
+
+var IPAPIFieldMapping = FieldMapping{
+	City:          "city",
+	District:      "district",
+	Region:        "regionName",
+	Country:       "country",
+	CountryCode:   "countryCode",
+	Continent:     "continent",
+	ContinentCode: "continentCode",
+	Timezone:      "timezone",
+	Lat:           "lat",
+	Lon:           "lon",
+	Status:        "status",
+	Message:       "message",
+}
+
+// HTTPProvider is a GeoProvider for any JSON-over-GET API whose response
+// is a flat object, described by a FieldMapping.
+type HTTPProvider struct {
+	getter    HTTPGetter
+	urlFormat string
+	mapping   FieldMapping
+}
+
+// NewHTTPProvider builds a provider requesting fmt.Sprintf(urlFormat, ip).
+// The mapping must at least name the city and country keys, and no two
+// fields may share a key.
+func NewHTTPProvider(getter HTTPGetter, urlFormat string, mapping FieldMapping) (*HTTPProvider, error) {
+	if strings.Count(urlFormat, "%s") != 1 {
+		return nil, fmt.Errorf("url format %q must contain exactly one %%s for the ip", urlFormat)
+	}
+	if mapping.City == "" || mapping.Country == "" {
+		return nil, errors.New("field mapping must name the city and country keys")
+	}
+
+	seen := make(map[string]bool)
+	for _, key := range []string{
+		mapping.City, mapping.District, mapping.Region, mapping.Country, mapping.CountryCode,
+		mapping.Continent, mapping.ContinentCode, mapping.Timezone, mapping.Lat, mapping.Lon,
+		mapping.Status, mapping.Success, mapping.Message,
+	} {
+		if key == "" {
+			continue
+		}
+		if strings.TrimSpace(key) != key {
+			return nil, fmt.Errorf("field mapping key %q has surrounding whitespace", key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("field mapping uses key %q more than once", key)
+		}
+		seen[key] = true
+	}
+
+	return &HTTPProvider{getter: getter, urlFormat: urlFormat, mapping: mapping}, nil
+}
+
+func NewIPAPIProvider(getter HTTPGetter) *HTTPProvider {
+	p, _ := NewHTTPProvider(getter, "http://ip-api.com/json/%s?fields=status,message,country,countryCode,continent,continentCode,regionName,city,district,lat,lon,timezone", IPAPIFieldMapping)
+	return p
+}
+
+// NewIPWhoisProvider uses ipwho.is. Its timezone is a nested object, which
+// FieldMapping can't describe, so Timezone is left empty.
+func NewIPWhoisProvider(getter HTTPGetter) *HTTPProvider {
+	p, _ := NewHTTPProvider(getter, "https://ipwho.is/%s", FieldMapping{
+		City:          "city",
+		Region:        "region",
+		Country:       "country",
+		CountryCode:   "country_code",
+		Continent:     "continent",
+		ContinentCode: "continent_code",
+		Lat:           "latitude",
+		Lon:           "longitude",
+		Success:       "success",
+		Message:       "message",
+	})
+	return p
+}
+
+func (p *HTTPProvider) Lookup(ctx context.Context, ip string) (LocationDetails, error) {
+	resp, err := getContext(ctx, p.getter, fmt.Sprintf(p.urlFormat, ip))
+	if err != nil {
+		return LocationDetails{}, lookupError(CategoryNetwork, "HTTP GET", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return LocationDetails{}, lookupError(CategoryNetwork, "read body", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return LocationDetails{}, lookupError(CategoryParse, "JSON unmarshal", err)
+	}
+
+	m := p.mapping
+	if m.Status != "" && stringField(fields, m.Status) == "fail" {
+		return LocationDetails{}, apiError(stringField(fields, m.Message))
+	}
+	if m.Success != "" && !boolField(fields, m.Success, true) {
+		return LocationDetails{}, apiError(stringField(fields, m.Message))
+	}
+
+	return LocationDetails{
+		IP:            ip,
+		City:          stringField(fields, m.City),
+		District:      stringField(fields, m.District),
+		Region:        stringField(fields, m.Region),
+		Country:       stringField(fields, m.Country),
+		CountryCode:   stringField(fields, m.CountryCode),
+		Continent:     stringField(fields, m.Continent),
+		ContinentCode: stringField(fields, m.ContinentCode),
+		Timezone:      stringField(fields, m.Timezone),
+		Lat:           floatField(fields, m.Lat),
+		Lon:           floatField(fields, m.Lon),
+	}, nil
+}
+
+// stringField and floatField treat missing or mistyped keys as empty.
+func stringField(fields map[string]json.RawMessage, key string) string {
+	var s string
+	if raw, ok := fields[key]; ok {
+		json.Unmarshal(raw, &s)
+	}
+	return s
+}
+
+// boolField returns def when key is missing or not a boolean.
+func boolField(fields map[string]json.RawMessage, key string, def bool) bool {
+	b := def
+	if raw, ok := fields[key]; ok {
+		if json.Unmarshal(raw, &b) != nil {
+			b = def
+		}
+	}
+	return b
+}
+
+func floatField(fields map[string]json.RawMessage, key string) float64 {
+	var f float64
+	if raw, ok := fields[key]; ok {
+		json.Unmarshal(raw, &f)
+	}
+	return f
+}
@@ -0,0 +1,150 @@
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestHTTPProvider_RemappedSchema(t *testing.T) {
+	getter := &stubGetter{bodies: map[string]string{
+		"https://geo.example.com/8.8.8.8": `{
+			"city_name": "Mountain View",
+			"region_name": "California",
+			"country_name": "United States",
+			"country_code": "US",
+			"latitude": 37.386,
+			"longitude": -122.0838
+		}`,
+	}}
+	p, err := NewHTTPProvider(getter, "https://geo.example.com/%s", FieldMapping{
+		City:        "city_name",
+		Region:      "region_name",
+		Country:     "country_name",
+		CountryCode: "country_code",
+		Timezone:    "time_zone",
+		Lat:         "latitude",
+		Lon:         "longitude",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := p.Lookup(context.Background(), "8.8.8.8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LocationDetails{
+		IP:          "8.8.8.8",
+		City:        "Mountain View",
+		Region:      "California",
+		Country:     "United States",
+		CountryCode: "US",
+		Lat:         37.386,
+		Lon:         -122.0838,
+	}
+	if got != want {
+		t.Errorf("Lookup = %+v, want %+v", got, want)
+	}
+}
+
+func TestHTTPProvider_IPAPIDistrict(t *testing.T) {
+	const url = "http://ip-api.com/json/%s?fields=status,message,country,countryCode,continent,continentCode,regionName,city,district,lat,lon,timezone"
+	getter := &stubGetter{bodies: map[string]string{
+		fmt.Sprintf(url, "41.67.0.1"): `{"status":"success","city":"Khartoum","district":"Khartoum North",` +
+			`"regionName":"Khartoum","country":"Sudan","countryCode":"SD","continent":"Africa","continentCode":"AF"}`,
+		fmt.Sprintf(url, "8.8.8.8"): `{"status":"success","city":"Ashburn","regionName":"Virginia",` +
+			`"country":"United States","countryCode":"US","continent":"North America","continentCode":"NA"}`,
+	}}
+	p := NewIPAPIProvider(getter)
+
+	tests := map[string]LocationDetails{
+		"41.67.0.1": {IP: "41.67.0.1", City: "Khartoum", District: "Khartoum North", Region: "Khartoum",
+			Country: "Sudan", CountryCode: "SD", Continent: "Africa", ContinentCode: "AF"},
+		"8.8.8.8": {IP: "8.8.8.8", City: "Ashburn", Region: "Virginia",
+			Country: "United States", CountryCode: "US", Continent: "North America", ContinentCode: "NA"},
+	}
+	for ip, want := range tests {
+		got, err := p.Lookup(context.Background(), ip)
+		if err != nil {
+			t.Fatalf("%s: %v", ip, err)
+		}
+		if got != want {
+			t.Errorf("Lookup(%s) = %+v, want %+v", ip, got, want)
+		}
+	}
+}
+
+func TestHTTPProvider_IPAPIFailure(t *testing.T) {
+	getter := &stubGetter{bodies: map[string]string{
+		"http://ip-api.com/json/999.1.1.1?fields=status,message,country,countryCode,continent,continentCode,regionName,city,district,lat,lon,timezone": `{"status":"fail","message":"invalid query"}`,
+	}}
+	if _, err := NewIPAPIProvider(getter).Lookup(context.Background(), "999.1.1.1"); err == nil {
+		t.Fatal("expected an error for a failed lookup")
+	}
+}
+
+func TestNewHTTPProvider_InvalidMapping(t *testing.T) {
+	tests := map[string]struct {
+		url     string
+		mapping FieldMapping
+	}{
+		"no placeholder": {"https://geo.example.com/", IPAPIFieldMapping},
+		"missing city":   {"https://geo.example.com/%s", FieldMapping{Country: "country"}},
+		"duplicate key":  {"https://geo.example.com/%s", FieldMapping{City: "name", Country: "name"}},
+		"whitespace key": {"https://geo.example.com/%s", FieldMapping{City: " city", Country: "country"}},
+	}
+	for name, tt := range tests {
+		if _, err := NewHTTPProvider(&stubGetter{}, tt.url, tt.mapping); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestHTTPProvider_IPWhoisFailure(t *testing.T) {
+	getter := &stubGetter{bodies: map[string]string{
+		"https://ipwho.is/10.0.0.1": `{"ip":"10.0.0.1","success":false,"message":"Reserved range"}`,
+	}}
+	_, err := NewIPWhoisProvider(getter).Lookup(context.Background(), "10.0.0.1")
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || appErr.Type() != ErrReservedRange {
+		t.Fatalf("err = %v, want ApplicationError of type %s", err, ErrReservedRange)
+	}
+}
+
+func TestHTTPProvider_SharedGetter(t *testing.T) {
+	doer := &recordingDoer{body: `{"status":"success","city":"Sydney","country":"Australia"}`}
+	a := &IPActivities{HTTPClient: doer, MaxConcurrent: 1}
+	p := NewIPAPIProvider(SharedGetter(a))
+
+	if _, err := p.Lookup(context.Background(), "1.1.1.1"); err != nil {
+		t.Fatal(err)
+	}
+	if doer.req == nil {
+		t.Fatal("request did not go through the activities' HTTPClient")
+	}
+	if got := a.latency.stats().Count; got != 1 {
+		t.Errorf("latency samples = %d, want 1", got)
+	}
+
+	// With the only slot taken, a lookup waits for it and gives up with
+	// its context.
+	release, err := a.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	doer.req = nil
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Lookup(ctx, "1.1.1.1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context's deadline", err)
+	}
+	if doer.req != nil {
+		t.Error("request sent without a MaxConcurrent slot")
+	}
+}
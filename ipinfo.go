@@ -0,0 +1,110 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrProviderRateLimited means the provider answered 429. Retrying
+	// later, as the activity retry policy does, is the right response.
+	ErrProviderRateLimited = errors.New("provider rate limit exceeded")
+	// ErrProviderUnauthorized means the provider rejected the API key.
+	// ProviderLookup does not retry it.
+	ErrProviderUnauthorized = errors.New("provider rejected credentials")
+)
+
+// IPInfoProvider is a GeoProvider for ipinfo.io.
+type IPInfoProvider struct {
+	token  string
+	getter HTTPGetter
+}
+
+func NewIPInfoProvider(token string, getter HTTPGetter) *IPInfoProvider {
+	return &IPInfoProvider{token: token, getter: getter}
+}
+
+func (p *IPInfoProvider) Lookup(ctx context.Context, ip string) (LocationDetails, error) {
+	u := "https://ipinfo.io/" + url.PathEscape(ip) + "/json?token=" + url.QueryEscape(p.token)
+	resp, err := getContext(ctx, p.getter, u)
+	if err != nil {
+		// SharedGetter reports a 429 as a LookupError before we see the
+		// response.
+		if ErrorCategoryOf(err) == CategoryRateLimit {
+			return LocationDetails{}, lookupError(CategoryRateLimit, "ipinfo", fmt.Errorf("%w: %v", ErrProviderRateLimited, err))
+		}
+		return LocationDetails{}, lookupError(CategoryNetwork, "HTTP GET", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return LocationDetails{}, lookupError(CategoryRateLimit, "ipinfo", ErrProviderRateLimited)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return LocationDetails{}, lookupError(CategoryProviderFail, "ipinfo", ErrProviderUnauthorized)
+	case resp.StatusCode == http.StatusNotFound:
+		return LocationDetails{}, fmt.Errorf("ipinfo %s: %w", ip, ErrLocationNotFound)
+	case resp.StatusCode != http.StatusOK:
+		return LocationDetails{}, lookupError(CategoryProviderFail, "ipinfo", fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return LocationDetails{}, lookupError(CategoryNetwork, "read body", err)
+	}
+
+	var data struct {
+		City     string `json:"city"`
+		Region   string `json:"region"`
+		Country  string `json:"country"`
+		Loc      string `json:"loc"`
+		Timezone string `json:"timezone"`
+		Bogon    bool   `json:"bogon"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return LocationDetails{}, lookupError(CategoryParse, "JSON unmarshal", err)
+	}
+	if data.Bogon {
+		return LocationDetails{}, apiError("reserved range")
+	}
+
+	lat, lon, err := parseLoc(data.Loc)
+	if err != nil {
+		return LocationDetails{}, lookupError(CategoryParse, "ipinfo", err)
+	}
+
+	// ipinfo's country is the ISO code; it has no full country name.
+	return LocationDetails{
+		IP:          ip,
+		City:        data.City,
+		Region:      data.Region,
+		CountryCode: data.Country,
+		Timezone:    data.Timezone,
+		Lat:         lat,
+		Lon:         lon,
+	}, nil
+}
+
+// parseLoc splits ipinfo's "lat,lon" field. An empty loc is 0, 0.
+func parseLoc(loc string) (lat, lon float64, err error) {
+	if loc == "" {
+		return 0, 0, nil
+	}
+	latStr, lonStr, ok := strings.Cut(loc, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed loc %q", loc)
+	}
+	if lat, err = strconv.ParseFloat(latStr, 64); err != nil {
+		return 0, 0, fmt.Errorf("malformed loc %q: %w", loc, err)
+	}
+	if lon, err = strconv.ParseFloat(lonStr, 64); err != nil {
+		return 0, 0, fmt.Errorf("malformed loc %q: %w", loc, err)
+	}
+	return lat, lon, nil
+}
@@ -0,0 +1,118 @@
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// statusGetter answers every request with the same status and body.
+type statusGetter struct {
+	status int
+	body   string
+	url    string
+}
+
+func (s *statusGetter) Get(url string) (*http.Response, error) {
+	s.url = url
+	return &http.Response{
+		StatusCode: s.status,
+		Status:     http.StatusText(s.status),
+		Body:       io.NopCloser(strings.NewReader(s.body)),
+	}, nil
+}
+
+func TestIPInfoProvider(t *testing.T) {
+	getter := &statusGetter{status: http.StatusOK, body: `{
+		"ip": "8.8.8.8",
+		"city": "Mountain View",
+		"region": "California",
+		"country": "US",
+		"loc": "37.4056,-122.0775",
+		"timezone": "America/Los_Angeles"
+	}`}
+	p := NewIPInfoProvider("secret", getter)
+
+	got, err := p.Lookup(context.Background(), "8.8.8.8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if getter.url != "https://ipinfo.io/8.8.8.8/json?token=secret" {
+		t.Errorf("url = %q", getter.url)
+	}
+	want := LocationDetails{
+		IP:          "8.8.8.8",
+		City:        "Mountain View",
+		Region:      "California",
+		CountryCode: "US",
+		Timezone:    "America/Los_Angeles",
+		Lat:         37.4056,
+		Lon:         -122.0775,
+	}
+	if got != want {
+		t.Errorf("Lookup = %+v, want %+v", got, want)
+	}
+}
+
+func TestIPInfoProvider_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{http.StatusTooManyRequests, `{}`, ErrProviderRateLimited},
+		{http.StatusUnauthorized, `{}`, ErrProviderUnauthorized},
+		{http.StatusForbidden, `{}`, ErrProviderUnauthorized},
+		{http.StatusNotFound, `{}`, ErrLocationNotFound},
+	} {
+		p := NewIPInfoProvider("secret", &statusGetter{status: tc.status, body: tc.body})
+		if _, err := p.Lookup(context.Background(), "8.8.8.8"); !errors.Is(err, tc.want) {
+			t.Errorf("status %d: err = %v, want %v", tc.status, err, tc.want)
+		}
+	}
+
+	p := NewIPInfoProvider("secret", &statusGetter{status: http.StatusOK, body: `{"ip":"10.0.0.1","bogon":true}`})
+	var appErr *temporal.ApplicationError
+	if _, err := p.Lookup(context.Background(), "10.0.0.1"); !errors.As(err, &appErr) || appErr.Type() != ErrReservedRange {
+		t.Errorf("bogon: err = %v, want %s", err, ErrReservedRange)
+	}
+}
+
+func TestParseLoc(t *testing.T) {
+	if lat, lon, err := parseLoc("-33.8688,151.2093"); err != nil || lat != -33.8688 || lon != 151.2093 {
+		t.Errorf("parseLoc = %v, %v, %v", lat, lon, err)
+	}
+	for _, bad := range []string{"37.4", "north,west"} {
+		if _, _, err := parseLoc(bad); err == nil {
+			t.Errorf("parseLoc(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestIPInfoProvider_CarriesContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "lookup")
+	doer := &recordingDoer{body: `{"city":"Sydney","country":"AU","loc":"-33.8,151.2"}`}
+	if _, err := NewIPInfoProvider("tok", doer).Lookup(ctx, "1.1.1.1"); err != nil {
+		t.Fatal(err)
+	}
+	if doer.req == nil || doer.req.Context().Value(key{}) != "lookup" {
+		t.Error("request did not carry the lookup's context")
+	}
+}
+
+func TestIPInfoProvider_RateLimitedThroughSharedGetter(t *testing.T) {
+	a := &IPActivities{HTTPClient: &statusGetter{status: http.StatusTooManyRequests, body: `{}`}}
+	_, err := NewIPInfoProvider("secret", SharedGetter(a)).Lookup(context.Background(), "8.8.8.8")
+	if !errors.Is(err, ErrProviderRateLimited) {
+		t.Errorf("err = %v, want %v", err, ErrProviderRateLimited)
+	}
+	if got := ErrorCategoryOf(err); got != CategoryRateLimit {
+		t.Errorf("category = %q, want %q", got, CategoryRateLimit)
+	}
+}
@@ -0,0 +1,42 @@
+package iplocate
+
+import (
+	"net"
+	"strings"
+)
+
+// NormalizeIPs validates and deduplicates inputs, keeping the order in which
+// each address first appears. Single-host CIDRs (/32, /128) are expanded to
+// their address; anything else, including wider CIDRs, is returned in
+// rejected. It does no I/O, so it is safe to call from workflow code.
+func NormalizeIPs(inputs []string) (ips []string, rejected []string) {
+	seen := make(map[string]bool)
+	for _, in := range inputs {
+		ip := parseHost(strings.TrimSpace(in))
+		if ip == nil {
+			rejected = append(rejected, in)
+			continue
+		}
+		s := ip.String()
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		ips = append(ips, s)
+	}
+	return ips, rejected
+}
+
+func parseHost(s string) net.IP {
+	if !strings.Contains(s, "/") {
+		return net.ParseIP(s)
+	}
+	ip, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil
+	}
+	if ones, bits := network.Mask.Size(); ones != bits {
+		return nil
+	}
+	return ip
+}
@@ -0,0 +1,32 @@
+package iplocate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeIPs(t *testing.T) {
+	inputs := []string{
+		"8.8.8.8",
+		" 1.1.1.1 ",
+		"8.8.8.8",
+		"8.8.8.8/32",
+		"9.9.9.9/32",
+		"2001:4860:4860::8888",
+		"2001:4860:4860:0::8888/128",
+		"10.0.0.0/8",
+		"not-an-ip",
+		"",
+	}
+
+	ips, rejected := NormalizeIPs(inputs)
+
+	wantIPs := []string{"8.8.8.8", "1.1.1.1", "9.9.9.9", "2001:4860:4860::8888"}
+	if !reflect.DeepEqual(ips, wantIPs) {
+		t.Errorf("ips = %v, want %v", ips, wantIPs)
+	}
+	wantRejected := []string{"10.0.0.0/8", "not-an-ip", ""}
+	if !reflect.DeepEqual(rejected, wantRejected) {
+		t.Errorf("rejected = %v, want %v", rejected, wantRejected)
+	}
+}
@@ -0,0 +1,79 @@
+package iplocate
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+)
+
+const latencyWindow = 512
+
+// LatencyStats is an approximation: percentiles are computed over the last
+// latencyWindow requests only, not over the lifetime of the worker.
+type LatencyStats struct {
+	Count int     `json:"count"`
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	P99Ms float64 `json:"p99_ms"`
+}
+
+type latencyRing struct {
+	mu   sync.Mutex
+	buf  [latencyWindow]time.Duration
+	next int
+	full bool
+}
+
+func (r *latencyRing) record(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buf[r.next] = d
+	r.next = (r.next + 1) % latencyWindow
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (r *latencyRing) stats() LatencyStats {
+	r.mu.Lock()
+	n := r.next
+	if r.full {
+		n = latencyWindow
+	}
+	samples := make([]time.Duration, n)
+	copy(samples, r.buf[:n])
+	r.mu.Unlock()
+
+	if n == 0 {
+		return LatencyStats{}
+	}
+	sort.Slice(samples, func(a, b int) bool { return samples[a] < samples[b] })
+
+	return LatencyStats{
+		Count: n,
+		P50Ms: percentile(samples, 50),
+		P95Ms: percentile(samples, 95),
+		P99Ms: percentile(samples, 99),
+	}
+}
+
+// percentile uses the nearest-rank method on already sorted samples.
+func percentile(sorted []time.Duration, p int) float64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1]) / float64(time.Millisecond)
+}
+
+// PublishLatency exposes the LatencyStats of a under name in expvar, which
+// serves it at /debug/vars on http.DefaultServeMux. It panics if name is
+// already taken. This is a function rather than a method because every
+// exported method of IPActivities is registered as an activity.
+func PublishLatency(name string, a *IPActivities) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return a.latency.stats()
+	}))
+}
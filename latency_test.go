@@ -0,0 +1,44 @@
+package iplocate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyRing_Percentiles(t *testing.T) {
+	var r latencyRing
+	for ms := 1; ms <= 100; ms++ {
+		r.record(time.Duration(ms) * time.Millisecond)
+	}
+
+	got := r.stats()
+	want := LatencyStats{Count: 100, P50Ms: 50, P95Ms: 95, P99Ms: 99}
+	if got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestLatencyRing_KeepsOnlyRecentWindow(t *testing.T) {
+	var r latencyRing
+	for i := 0; i < latencyWindow; i++ {
+		r.record(time.Second)
+	}
+	for i := 0; i < latencyWindow; i++ {
+		r.record(time.Millisecond)
+	}
+
+	got := r.stats()
+	if got.Count != latencyWindow {
+		t.Errorf("count = %d, want %d", got.Count, latencyWindow)
+	}
+	if got.P99Ms != 1 {
+		t.Errorf("p99 = %vms, want 1ms once old samples are overwritten", got.P99Ms)
+	}
+}
+
+func TestLatencyRing_Empty(t *testing.T) {
+	var r latencyRing
+	if got := r.stats(); got != (LatencyStats{}) {
+		t.Errorf("stats = %+v, want zero value", got)
+	}
+}
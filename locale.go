@@ -0,0 +1,67 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type LocaleInfo struct {
+	CountryCode string
+	Currency    string
+	// CallingCode is the international dialling prefix, e.g. "+44". It is
+	// empty for countries missing from callingCodes.
+	CallingCode string
+}
+
+// callingCodes is bundled because ip-api doesn't return calling codes.
+var callingCodes = map[string]string{
+	"AE": "+971", "AR": "+54", "AT": "+43", "AU": "+61", "BD": "+880",
+	"BE": "+32", "BR": "+55", "CA": "+1", "CH": "+41", "CL": "+56",
+	"CN": "+86", "CO": "+57", "CZ": "+420", "DE": "+49", "DK": "+45",
+	"DZ": "+213", "EG": "+20", "ES": "+34", "ET": "+251", "FI": "+358",
+	"FR": "+33", "GB": "+44", "GH": "+233", "GR": "+30", "HK": "+852",
+	"HU": "+36", "ID": "+62", "IE": "+353", "IL": "+972", "IN": "+91",
+	"IQ": "+964", "IR": "+98", "IT": "+39", "JO": "+962", "JP": "+81",
+	"KE": "+254", "KR": "+82", "KW": "+965", "LB": "+961", "MA": "+212",
+	"MX": "+52", "MY": "+60", "NG": "+234", "NL": "+31", "NO": "+47",
+	"NZ": "+64", "OM": "+968", "PE": "+51", "PH": "+63", "PK": "+92",
+	"PL": "+48", "PT": "+351", "QA": "+974", "RO": "+40", "RU": "+7",
+	"SA": "+966", "SD": "+249", "SE": "+46", "SG": "+65", "SS": "+211",
+	"TH": "+66", "TN": "+216", "TR": "+90", "TW": "+886", "TZ": "+255",
+	"UA": "+380", "UG": "+256", "US": "+1", "VN": "+84", "ZA": "+27",
+}
+
+func (i *IPActivities) GetLocaleInfo(ctx context.Context, ip string) (LocaleInfo, error) {
+	url := "http://ip-api.com/json/" + ip + "?fields=status,message,countryCode,currency"
+
+	resp, err := i.get(ctx, url)
+	if err != nil {
+		return LocaleInfo{}, lookupError(CategoryNetwork, "HTTP GET", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return LocaleInfo{}, lookupError(CategoryNetwork, "read body", err)
+	}
+
+	var data struct {
+		Status      string `json:"status"`
+		Message     string `json:"message"`
+		CountryCode string `json:"countryCode"`
+		Currency    string `json:"currency"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return LocaleInfo{}, lookupError(CategoryParse, "JSON unmarshal", err)
+	}
+
+	if data.Status == "fail" {
+		return LocaleInfo{}, apiError(data.Message)
+	}
+
+	return LocaleInfo{
+		CountryCode: data.CountryCode,
+		Currency:    data.Currency,
+		CallingCode: callingCodes[data.CountryCode],
+	}, nil
+}
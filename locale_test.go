@@ -0,0 +1,29 @@
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+func TestIPActivities_GetLocaleInfo(t *testing.T) {
+	a := &IPActivities{HTTPClient: &stubGetter{bodies: map[string]string{
+		"http://ip-api.com/json/41.67.0.1?fields=status,message,countryCode,currency": `{"status":"success","countryCode":"SD","currency":"SDG"}`,
+		"http://ip-api.com/json/10.0.0.1?fields=status,message,countryCode,currency":  `{"status":"fail","message":"private range"}`,
+	}}}
+
+	got, err := a.GetLocaleInfo(context.Background(), "41.67.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (LocaleInfo{CountryCode: "SD", Currency: "SDG", CallingCode: "+249"}); got != want {
+		t.Errorf("GetLocaleInfo = %+v, want %+v", got, want)
+	}
+
+	var appErr *temporal.ApplicationError
+	if _, err := a.GetLocaleInfo(context.Background(), "10.0.0.1"); !errors.As(err, &appErr) || appErr.Type() != ErrReservedRange {
+		t.Errorf("err = %v, want %s", err, ErrReservedRange)
+	}
+}
@@ -0,0 +1,96 @@
+package iplocate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// LoggingGetter wraps an HTTPGetter and logs every request's method, URL,
+// status and latency. It also implements Do, passing requests through to
+// the wrapped client when it supports them, so wrapping an *http.Client
+// keeps the context and correlation header that IPActivities sends.
+type LoggingGetter struct {
+	Getter HTTPGetter
+	// Logger defaults to slog.Default().
+	Logger *slog.Logger
+	// LogBodySize delays the log record until the body is closed so it can
+	// include the number of bytes read.
+	LogBodySize bool
+}
+
+func NewLoggingGetter(getter HTTPGetter, logger *slog.Logger) *LoggingGetter {
+	return &LoggingGetter{Getter: getter, Logger: logger}
+}
+
+func (g *LoggingGetter) Get(url string) (*http.Response, error) {
+	start := time.Now()
+	resp, err := g.Getter.Get(url)
+	return g.log(context.Background(), http.MethodGet, url, start, resp, err)
+}
+
+func (g *LoggingGetter) Do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	var resp *http.Response
+	var err error
+	if doer, ok := g.Getter.(httpDoer); ok {
+		resp, err = doer.Do(req)
+	} else if req.Method == http.MethodGet {
+		resp, err = g.Getter.Get(req.URL.String())
+	} else {
+		// Get would drop the method, body and headers.
+		err = fmt.Errorf("HTTPClient %T can't send %s requests", g.Getter, req.Method)
+	}
+	return g.log(req.Context(), req.Method, req.URL.String(), start, resp, err)
+}
+
+func (g *LoggingGetter) log(ctx context.Context, method, url string, start time.Time, resp *http.Response, err error) (*http.Response, error) {
+	logger := g.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("url", url),
+		slog.Duration("latency", time.Since(start)),
+	}
+
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelWarn, "http request failed", append(attrs, slog.Any("error", err))...)
+		return resp, err
+	}
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+
+	if !g.LogBodySize {
+		logger.LogAttrs(ctx, slog.LevelInfo, "http request", attrs...)
+		return resp, nil
+	}
+	resp.Body = &countingBody{ReadCloser: resp.Body, onClose: func(n int64) {
+		logger.LogAttrs(ctx, slog.LevelInfo, "http request", append(attrs, slog.Int64("body_bytes", n))...)
+	}}
+	return resp, nil
+}
+
+type countingBody struct {
+	io.ReadCloser
+	n       int64
+	closed  bool
+	onClose func(n int64)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	if !b.closed {
+		b.closed = true
+		b.onClose(b.n)
+	}
+	return b.ReadCloser.Close()
+}
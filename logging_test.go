@@ -0,0 +1,68 @@
+package iplocate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLoggingGetter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	doer := &recordingDoer{body: `{"status":"success","timezone":"Australia/Sydney"}`}
+	g := &LoggingGetter{Getter: doer, Logger: logger, LogBodySize: true}
+
+	a := &IPActivities{HTTPClient: g}
+	zone, err := a.GetTimeZone(context.Background(), "1.1.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zone != "Australia/Sydney" {
+		t.Errorf("zone = %q, response not passed through", zone)
+	}
+	if doer.req == nil {
+		t.Fatal("request did not reach the wrapped client's Do")
+	}
+
+	var record struct {
+		Msg       string `json:"msg"`
+		Method    string `json:"method"`
+		URL       string `json:"url"`
+		Status    int    `json:"status"`
+		BodyBytes int    `json:"body_bytes"`
+		Latency   *int64 `json:"latency"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log output %q: %v", buf.String(), err)
+	}
+	if record.Msg != "http request" || record.Method != "GET" || record.Status != 200 {
+		t.Errorf("log record = %+v", record)
+	}
+	if record.URL != "http://ip-api.com/json/1.1.1.1?fields=timezone" {
+		t.Errorf("url = %q", record.URL)
+	}
+	if record.BodyBytes != len(doer.body) || record.Latency == nil {
+		t.Errorf("body_bytes = %d, latency = %v", record.BodyBytes, record.Latency)
+	}
+}
+
+func TestLoggingGetter_RejectsNonGETWithoutDo(t *testing.T) {
+	getter := &stubGetter{bodies: map[string]string{"https://example.com/hook": "ok"}}
+	g := &LoggingGetter{Getter: getter, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/hook", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Do(req); err == nil {
+		t.Error("POST through a getter without Do succeeded")
+	}
+	if n := getter.count("https://example.com/hook"); n != 0 {
+		t.Errorf("POST was sent as %d GETs", n)
+	}
+}
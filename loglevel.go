@@ -0,0 +1,55 @@
+package iplocate
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// LogLevelEnv selects the worker's log level: debug, info, warn or error.
+const LogLevelEnv = "LOG_LEVEL"
+
+// ParseLogLevel maps a LogLevelEnv value to a slog.Level. Empty means info.
+func ParseLogLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+// NewLogger writes text logs at level and above to w. Wrap it with
+// go.temporal.io/sdk/log.NewStructuredLogger for client.Options.Logger so
+// the SDK, workflow and activity loggers all share the level.
+func NewLogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// SetDefaultLogger makes logger the slog default, which LoggingGetter and
+// anything else logging through slog.Default() use. Unlike slog.SetDefault
+// it leaves the standard log package writing where it did, so log.Fatal
+// messages still print when LogLevelEnv filters out info.
+func SetDefaultLogger(logger *slog.Logger) {
+	w, flags := log.Writer(), log.Flags()
+	slog.SetDefault(logger)
+	log.SetOutput(w)
+	log.SetFlags(flags)
+}
+
+// LoggerFromEnv is NewLogger on stderr at the LogLevelEnv level.
+func LoggerFromEnv() (*slog.Logger, error) {
+	level, err := ParseLogLevel(os.Getenv(LogLevelEnv))
+	if err != nil {
+		return nil, err
+	}
+	return NewLogger(os.Stderr, level), nil
+}
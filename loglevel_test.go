@@ -0,0 +1,71 @@
+package iplocate
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	sdklog "go.temporal.io/sdk/log"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{
+		"":        slog.LevelInfo,
+		"debug":   slog.LevelDebug,
+		"INFO":    slog.LevelInfo,
+		"warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+	} {
+		if got, err := ParseLogLevel(in); err != nil || got != want {
+			t.Errorf("ParseLogLevel(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseLogLevel("verbose"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
+
+func TestNewLogger_SuppressesDebugAtInfo(t *testing.T) {
+	var buf bytes.Buffer
+	logger := sdklog.NewStructuredLogger(NewLogger(&buf, slog.LevelInfo))
+
+	logger.Debug("noisy detail")
+	logger.Info("worker started")
+
+	out := buf.String()
+	if strings.Contains(out, "noisy detail") {
+		t.Errorf("debug message logged at info level: %q", out)
+	}
+	if !strings.Contains(out, "worker started") {
+		t.Errorf("info message missing: %q", out)
+	}
+}
+
+func TestSetDefaultLogger_KeepsStdLog(t *testing.T) {
+	oldSlog, oldW, oldFlags := slog.Default(), log.Writer(), log.Flags()
+	t.Cleanup(func() {
+		slog.SetDefault(oldSlog)
+		log.SetOutput(oldW)
+		log.SetFlags(oldFlags)
+	})
+
+	var stdout, slogOut bytes.Buffer
+	log.SetOutput(&stdout)
+	level, err := ParseLogLevel("error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDefaultLogger(NewLogger(&slogOut, level))
+
+	// What log.Fatalln prints before exiting.
+	log.Println("error in dialing:", "connection refused")
+	if !strings.Contains(stdout.String(), "error in dialing: connection refused") {
+		t.Errorf("std log output = %q, want the message", stdout.String())
+	}
+	slog.Info("dropped")
+	if slogOut.Len() != 0 {
+		t.Errorf("slog default logged %q at error level", slogOut.String())
+	}
+}
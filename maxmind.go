@@ -0,0 +1,69 @@
+package iplocate
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// MaxMindProvider is a GeoProvider backed by a local GeoIP2 or GeoLite2
+// City database, so lookups need no network.
+type MaxMindProvider struct {
+	db *geoip2.Reader
+}
+
+// NewMaxMindProvider memory-maps the database at path.
+func NewMaxMindProvider(path string) (*MaxMindProvider, error) {
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open maxmind db: %w", err)
+	}
+	return &MaxMindProvider{db: db}, nil
+}
+
+// NewMaxMindProviderFromBytes reads the database from memory, e.g. one
+// embedded with //go:embed or fetched from object storage, for deployments
+// without a writable filesystem. db must not be modified afterwards.
+func NewMaxMindProviderFromBytes(db []byte) (*MaxMindProvider, error) {
+	reader, err := geoip2.FromBytes(db)
+	if err != nil {
+		return nil, fmt.Errorf("open maxmind db: %w", err)
+	}
+	return &MaxMindProvider{db: reader}, nil
+}
+
+func (p *MaxMindProvider) Close() error {
+	return p.db.Close()
+}
+
+func (p *MaxMindProvider) Lookup(ctx context.Context, ip string) (LocationDetails, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return LocationDetails{}, apiError("invalid query")
+	}
+	record, err := p.db.City(parsed)
+	if err != nil {
+		return LocationDetails{}, fmt.Errorf("maxmind lookup: %w", err)
+	}
+	if record.Country.IsoCode == "" && record.City.Names["en"] == "" {
+		return LocationDetails{}, fmt.Errorf("%s: %w", ip, ErrLocationNotFound)
+	}
+
+	details := LocationDetails{
+		IP:            ip,
+		City:          record.City.Names["en"],
+		Country:       record.Country.Names["en"],
+		CountryCode:   record.Country.IsoCode,
+		Continent:     record.Continent.Names["en"],
+		ContinentCode: record.Continent.Code,
+		Timezone:      record.Location.TimeZone,
+		Lat:           record.Location.Latitude,
+		Lon:           record.Location.Longitude,
+	}
+	if len(record.Subdivisions) > 0 {
+		details.Region = record.Subdivisions[0].Names["en"]
+	}
+	return details, nil
+}
@@ -0,0 +1,140 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net"
+	"sort"
+	"testing"
+)
+
+func TestMaxMindProviderFromBytes(t *testing.T) {
+	db := buildTestMMDB(t, "81.2.69.0/24", map[string]any{
+		"city":      map[string]any{"names": map[string]any{"en": "London"}},
+		"continent": map[string]any{"code": "EU", "names": map[string]any{"en": "Europe"}},
+		"country":   map[string]any{"iso_code": "GB", "names": map[string]any{"en": "United Kingdom"}},
+		"location": map[string]any{
+			"latitude":  51.5142,
+			"longitude": -0.0931,
+			"time_zone": "Europe/London",
+		},
+		"subdivisions": []any{map[string]any{"names": map[string]any{"en": "England"}}},
+	})
+
+	p, err := NewMaxMindProviderFromBytes(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	got, err := p.Lookup(context.Background(), "81.2.69.142")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LocationDetails{
+		IP:            "81.2.69.142",
+		City:          "London",
+		Region:        "England",
+		Country:       "United Kingdom",
+		CountryCode:   "GB",
+		Continent:     "Europe",
+		ContinentCode: "EU",
+		Timezone:      "Europe/London",
+		Lat:           51.5142,
+		Lon:           -0.0931,
+	}
+	if got != want {
+		t.Errorf("Lookup = %+v, want %+v", got, want)
+	}
+
+	if _, err := p.Lookup(context.Background(), "8.8.8.8"); !errors.Is(err, ErrLocationNotFound) {
+		t.Errorf("IP outside the db: err = %v, want ErrLocationNotFound", err)
+	}
+	if _, err := NewMaxMindProviderFromBytes([]byte("not a database")); err == nil {
+		t.Error("expected an error for a corrupt database")
+	}
+}
+
+// buildTestMMDB encodes an IPv4 GeoLite2-City database in the MaxMind DB
+// format holding record for the single network cidr. It supports just the
+// value types the test needs: maps, arrays, strings and doubles.
+func buildTestMMDB(t *testing.T, cidr string, record map[string]any) []byte {
+	t.Helper()
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix, _ := network.Mask.Size()
+	addr := binary.BigEndian.Uint32(network.IP.To4())
+
+	// One node per prefix bit; the other branch of each is empty, which
+	// the format marks with a pointer equal to the node count.
+	nodeCount := uint32(prefix)
+	var buf []byte
+	for i := 0; i < prefix; i++ {
+		next := uint32(i + 1)
+		if i == prefix-1 {
+			next = nodeCount + 16 // data section offset 0
+		}
+		records := [2]uint32{nodeCount, nodeCount}
+		records[addr>>(31-i)&1] = next
+		for _, r := range records {
+			buf = append(buf, byte(r>>16), byte(r>>8), byte(r))
+		}
+	}
+	buf = append(buf, make([]byte, 16)...)
+	buf = append(buf, mmdbEncode(record)...)
+
+	buf = append(buf, "\xab\xcd\xefMaxMind.com"...)
+	buf = append(buf, mmdbEncode(map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"database_type":               "GeoLite2-City",
+		"ip_version":                  uint16(4),
+		"languages":                   []any{"en"},
+		"node_count":                  nodeCount,
+		"record_size":                 uint16(24),
+	})...)
+	return buf
+}
+
+func mmdbControl(typ, size int) []byte {
+	if typ > 7 {
+		return []byte{byte(size), byte(typ - 7)}
+	}
+	return []byte{byte(typ<<5 | size)}
+}
+
+func mmdbEncode(v any) []byte {
+	switch v := v.(type) {
+	case string:
+		return append(mmdbControl(2, len(v)), v...)
+	case float64:
+		return binary.BigEndian.AppendUint64(mmdbControl(3, 8), math.Float64bits(v))
+	case uint16:
+		return binary.BigEndian.AppendUint16(mmdbControl(5, 2), v)
+	case uint32:
+		return binary.BigEndian.AppendUint32(mmdbControl(6, 4), v)
+	case []any:
+		out := mmdbControl(11, len(v))
+		for _, e := range v {
+			out = append(out, mmdbEncode(e)...)
+		}
+		return out
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := mmdbControl(7, len(v))
+		for _, k := range keys {
+			out = append(out, mmdbEncode(k)...)
+			out = append(out, mmdbEncode(v[k])...)
+		}
+		return out
+	}
+	panic("mmdbEncode: unsupported type")
+}
@@ -0,0 +1,102 @@
+// Command monitorctl checks saved workflow histories against the current
+// code, so CI can catch changes that need a GetVersion guard.
+//
+//	monitorctl export -workflow-id ID [-run-id RUN] [-out file.json] [-format json|ndjson]
+//	monitorctl replay -history file.json [-workflow GetAddressFromIPV2]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"temporal-ip-geolocation/iplocate"
+
+	"go.temporal.io/sdk/client"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "replay":
+		replay(os.Args[2:])
+	case "export":
+		export(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: monitorctl replay|export [flags]")
+	os.Exit(2)
+}
+
+func replay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	history := fs.String("history", "", "history JSON written by export")
+	workflowType := fs.String("workflow", "", "fail unless the history is of this workflow type")
+	fs.Parse(args)
+	if *history == "" {
+		log.Fatalln("-history is required")
+	}
+
+	if err := iplocate.ReplayHistoryFile(*history, *workflowType); err != nil {
+		log.Fatalln("Replay failed:", err)
+	}
+	log.Println("Replay succeeded:", *history)
+}
+
+func export(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	address := fs.String("address", "127.0.0.1:7233", "Temporal frontend address")
+	namespace := fs.String("namespace", "default", "Temporal namespace")
+	workflowID := fs.String("workflow-id", "", "workflow to export")
+	runID := fs.String("run-id", "", "run to export; the latest run when empty")
+	out := fs.String("out", "-", "file to write, or - for stdout")
+	format := fs.String("format", "json", "json for a replayable history, ndjson for one event per line")
+	fs.Parse(args)
+	if *workflowID == "" {
+		log.Fatalln("-workflow-id is required")
+	}
+	exportFn := iplocate.ExportHistory
+	switch *format {
+	case "json":
+	case "ndjson":
+		exportFn = iplocate.ExportHistoryNDJSON
+	default:
+		log.Fatalf("unknown -format %q, want json or ndjson", *format)
+	}
+	if *out != "-" {
+		if info, err := os.Stat(*out); err == nil && info.IsDir() {
+			log.Fatalf("-out %s is a directory", *out)
+		}
+	}
+
+	c, err := iplocate.Dial(client.Options{HostPort: *address, Namespace: *namespace})
+	if err != nil {
+		log.Fatalln("Unable to create client", err)
+	}
+	defer c.Close()
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	err = iplocate.CallWithTimeout(context.Background(), 0, func(ctx context.Context) error {
+		return exportFn(ctx, c, *workflowID, *runID, w)
+	})
+	if err != nil {
+		log.Fatalln("Export failed:", err)
+	}
+}
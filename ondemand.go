@@ -0,0 +1,69 @@
+package iplocate
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	// LookupIPSignal carries the IP string for OnDemandLookupWorkflow.
+	LookupIPSignal = "lookup-ip"
+	// StatusQuery reports "waiting", "looking up" or "done".
+	StatusQuery = "status"
+)
+
+// OnDemandLookupWorkflow waits for a LookupIPSignal and geolocates the IP it
+// carries. Started with SignalWithStartWorkflow it gives a request/response
+// lookup keyed by workflow ID. With a positive timeout the workflow fails if
+// no signal arrives in time; zero waits indefinitely.
+func OnDemandLookupWorkflow(ctx workflow.Context, timeout time.Duration) (Data, error) {
+	ao := ActivityConfig{}.Options()
+	var ipActivities *IPActivities
+	ctx = workflow.WithActivityOptions(ctx, ao)
+
+	status := "waiting"
+	err := workflow.SetQueryHandler(ctx, StatusQuery, func() (string, error) {
+		return status, nil
+	})
+	if err != nil {
+		return Data{}, err
+	}
+
+	var ip string
+	ch := workflow.GetSignalChannel(ctx, LookupIPSignal)
+	if timeout > 0 {
+		if ok, _ := ch.ReceiveWithTimeout(ctx, timeout, &ip); !ok {
+			return Data{}, fmt.Errorf("no %s signal received within %s", LookupIPSignal, timeout)
+		}
+	} else {
+		ch.Receive(ctx, &ip)
+	}
+	if net.ParseIP(ip) == nil {
+		return Data{}, fmt.Errorf("invalid ip in %s signal: %q", LookupIPSignal, ip)
+	}
+
+	status = "looking up"
+	workflow.GetLogger(ctx).Info("Lookup requested", "ip", ip)
+
+	var location string
+	err = workflow.ExecuteActivity(ctx, ipActivities.GetLocationInfo, ip).Get(ctx, &location)
+	if err != nil {
+		return Data{}, fmt.Errorf("failed to get location: %s", err)
+	}
+
+	var zone string
+	err = workflow.ExecuteActivity(ctx, ipActivities.GetTimeZone, ip).Get(ctx, &zone)
+	if err != nil {
+		return Data{}, fmt.Errorf("failed to get timezone: %s", err)
+	}
+
+	status = "done"
+	return Data{
+		Result:   ip,
+		Location: location,
+		Zone:     zone,
+	}, nil
+}
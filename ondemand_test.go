@@ -0,0 +1,56 @@
+package iplocate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestOnDemandLookupWorkflow_Signal(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.OnActivity("GetLocationInfo", mock.Anything, "1.1.1.1").Return("City: Sydney", nil)
+	env.OnActivity("GetTimeZone", mock.Anything, "1.1.1.1").Return("Australia/Sydney", nil)
+
+	env.RegisterDelayedCallback(func() {
+		var status string
+		if res, err := env.QueryWorkflow(StatusQuery); err != nil {
+			t.Error(err)
+		} else if err := res.Get(&status); err != nil {
+			t.Error(err)
+		} else if status != "waiting" {
+			t.Errorf("status before signal = %q, want waiting", status)
+		}
+		env.SignalWorkflow(LookupIPSignal, "1.1.1.1")
+	}, time.Minute)
+
+	env.ExecuteWorkflow(OnDemandLookupWorkflow, time.Duration(0))
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	var got Data
+	if err := env.GetWorkflowResult(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := Data{Result: "1.1.1.1", Location: "City: Sydney", Zone: "Australia/Sydney"}
+	if got != want {
+		t.Errorf("result = %+v, want %+v", got, want)
+	}
+}
+
+func TestOnDemandLookupWorkflow_Timeout(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.ExecuteWorkflow(OnDemandLookupWorkflow, time.Hour)
+
+	if err := env.GetWorkflowError(); err == nil {
+		t.Fatal("expected the workflow to time out waiting for a signal")
+	}
+}
@@ -0,0 +1,104 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// LocationDetails is a structured geolocation result, as opposed to the
+// display string returned by GetLocationInfo.
+type LocationDetails struct {
+	IP   string
+	City string
+	// District is a sub-region below City. Few providers report it, and
+	// ip-api only for some countries, so it is often empty.
+	District      string
+	Region        string
+	Country       string
+	CountryCode   string
+	Continent     string
+	ContinentCode string
+	Timezone      string
+	Lat           float64
+	Lon           float64
+}
+
+// GeoProvider resolves an IP to its location.
+type GeoProvider interface {
+	Lookup(ctx context.Context, ip string) (LocationDetails, error)
+}
+
+var ErrLocationNotFound = errors.New("location not found")
+
+// StaticProvider answers from a fixed map, for offline demos and tests.
+type StaticProvider struct {
+	locations map[string]LocationDetails
+	// Default is returned for IPs missing from the map. When nil, those
+	// lookups fail with ErrLocationNotFound.
+	Default *LocationDetails
+}
+
+func NewStaticProvider(locations map[string]LocationDetails) *StaticProvider {
+	copied := make(map[string]LocationDetails, len(locations))
+	for ip, details := range locations {
+		copied[ip] = details
+	}
+	return &StaticProvider{locations: copied}
+}
+
+func (p *StaticProvider) Lookup(ctx context.Context, ip string) (LocationDetails, error) {
+	details, ok := p.locations[ip]
+	if !ok {
+		if p.Default == nil {
+			return LocationDetails{}, fmt.Errorf("%s: %w", ip, ErrLocationNotFound)
+		}
+		details = *p.Default
+	}
+	details.IP = ip
+	return details, nil
+}
+
+// OverrideProvider answers pinned IPs from a file and passes every other
+// lookup to the wrapped provider.
+type OverrideProvider struct {
+	overrides map[string]LocationDetails
+	inner     GeoProvider
+}
+
+// NewOverrideProvider loads overrides from a JSON object mapping IPs to
+// LocationDetails, e.g. {"10.0.0.5": {"City": "Office", "CountryCode": "SD"}}.
+// Field names are matched case-insensitively.
+func NewOverrideProvider(path string, inner GeoProvider) (*OverrideProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]LocationDetails
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	overrides := make(map[string]LocationDetails, len(raw))
+	for ip, details := range raw {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return nil, fmt.Errorf("%s: invalid ip %q", path, ip)
+		}
+		overrides[parsed.String()] = details
+	}
+	return &OverrideProvider{overrides: overrides, inner: inner}, nil
+}
+
+func (p *OverrideProvider) Lookup(ctx context.Context, ip string) (LocationDetails, error) {
+	if parsed := net.ParseIP(ip); parsed != nil {
+		if details, ok := p.overrides[parsed.String()]; ok {
+			details.IP = ip
+			return details, nil
+		}
+	}
+	return p.inner.Lookup(ctx, ip)
+}
@@ -0,0 +1,79 @@
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStaticProvider(t *testing.T) {
+	p := NewStaticProvider(map[string]LocationDetails{
+		"8.8.8.8": {City: "Mountain View", Country: "United States", CountryCode: "US"},
+	})
+
+	got, err := p.Lookup(context.Background(), "8.8.8.8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LocationDetails{IP: "8.8.8.8", City: "Mountain View", Country: "United States", CountryCode: "US"}
+	if got != want {
+		t.Errorf("known IP = %+v, want %+v", got, want)
+	}
+
+	if _, err := p.Lookup(context.Background(), "1.1.1.1"); !errors.Is(err, ErrLocationNotFound) {
+		t.Errorf("unknown IP err = %v, want ErrLocationNotFound", err)
+	}
+
+	p.Default = &LocationDetails{Country: "Unknown"}
+	got, err = p.Lookup(context.Background(), "1.1.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.IP != "1.1.1.1" || got.Country != "Unknown" {
+		t.Errorf("unknown IP with default = %+v", got)
+	}
+}
+
+func TestOverrideProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	err := os.WriteFile(path, []byte(`{"10.0.0.5": {"City": "Head Office", "countryCode": "SD"}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := NewStaticProvider(map[string]LocationDetails{
+		"8.8.8.8": {City: "Mountain View", CountryCode: "US"},
+	})
+
+	p, err := NewOverrideProvider(path, inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := p.Lookup(context.Background(), "10.0.0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (LocationDetails{IP: "10.0.0.5", City: "Head Office", CountryCode: "SD"}); got != want {
+		t.Errorf("override hit = %+v, want %+v", got, want)
+	}
+
+	got, err = p.Lookup(context.Background(), "8.8.8.8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.City != "Mountain View" {
+		t.Errorf("fall-through = %+v, want the inner provider's answer", got)
+	}
+}
+
+func TestNewOverrideProvider_InvalidIP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	if err := os.WriteFile(path, []byte(`{"not-an-ip": {}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewOverrideProvider(path, NewStaticProvider(nil)); err == nil {
+		t.Error("expected an error for an invalid ip key")
+	}
+}
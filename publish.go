@@ -0,0 +1,40 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Publisher sends a message to a message-queue subject. A *nats.Conn
+// satisfies it as-is; Kafka or other brokers need a small adapter.
+type Publisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// NopPublisher drops every message. It is what PublishResult uses when
+// IPActivities.Publisher is nil.
+type NopPublisher struct{}
+
+func (NopPublisher) Publish(subject string, data []byte) error {
+	return nil
+}
+
+func (i *IPActivities) PublishResult(ctx context.Context, subject string, result Data) error {
+	publisher := i.Publisher
+	if publisher == nil {
+		publisher = NopPublisher{}
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshal result: %w", err)
+	}
+	// A slow broker must not trip the lookup's HeartbeatTimeout.
+	stop := heartbeat(ctx)
+	defer stop()
+	if err := publisher.Publish(subject, data); err != nil {
+		return fmt.Errorf("publish to %s: %w", subject, err)
+	}
+	return nil
+}
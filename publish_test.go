@@ -0,0 +1,50 @@
+package iplocate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/testsuite"
+)
+
+type capturingPublisher struct {
+	subject string
+	data    []byte
+}
+
+func (c *capturingPublisher) Publish(subject string, data []byte) error {
+	c.subject = subject
+	c.data = data
+	return nil
+}
+
+func TestGetAddressFromIPV2_PublishesResult(t *testing.T) {
+	publisher := &capturingPublisher{}
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{Publisher: publisher})
+
+	env.OnActivity("GetIP", mock.Anything).Return("8.8.8.8", nil)
+	env.OnActivity("RecordLookup", mock.Anything, "8.8.8.8", mock.Anything).Return("1-8.8.8.8", nil)
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("City: Ashburn", nil)
+	env.OnActivity("GetTimeZone", mock.Anything, "8.8.8.8").Return("America/New_York", nil)
+
+	env.ExecuteWorkflow(GetAddressFromIPV2, "", LookupOptions{PublishSubject: "lookups.done"})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	if publisher.subject != "lookups.done" {
+		t.Errorf("subject = %q, want lookups.done", publisher.subject)
+	}
+	var got Data
+	if err := json.Unmarshal(publisher.data, &got); err != nil {
+		t.Fatalf("payload is not a JSON Data: %v", err)
+	}
+	want := Data{Result: "8.8.8.8", Location: "City: Ashburn", Zone: "America/New_York"}
+	if got != want {
+		t.Errorf("published %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+package iplocate
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// reservedIPv4 lists the IANA special-purpose ranges that ip-api answers
+// with "reserved range" or "private range".
+var reservedIPv4 = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"192.88.99.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for k, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets[k] = n
+	}
+	return nets
+}
+
+func isReservedIPv4(ip net.IP) bool {
+	for _, n := range reservedIPv4 {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RandomPublicIPs returns n distinct random public IPv4 addresses. The seed
+// is recorded with SideEffect, so a replay generates the same addresses.
+func RandomPublicIPs(ctx workflow.Context, n int) []string {
+	var seed int64
+	encoded := workflow.SideEffect(ctx, func(workflow.Context) any {
+		return time.Now().UnixNano()
+	})
+	if err := encoded.Get(&seed); err != nil {
+		panic(err)
+	}
+
+	return publicIPs(rand.New(rand.NewSource(seed)), n)
+}
+
+func publicIPs(rng *rand.Rand, n int) []string {
+	ips := make([]string, 0, n)
+	// LoadTestWorkflow tracks progress by IP, so repeats would skew it.
+	seen := make(map[string]bool, n)
+	for len(ips) < n {
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, rng.Uint32())
+		if isReservedIPv4(ip) || seen[ip.String()] {
+			continue
+		}
+		seen[ip.String()] = true
+		ips = append(ips, ip.String())
+	}
+	return ips
+}
+
+// ProgressQuery reports a BatchProgress for LoadTestWorkflow.
+const ProgressQuery = "progress"
+
+// BatchProgress counts finished lookups, failed ones included. Results
+// maps each successfully looked-up IP to its location.
+type BatchProgress struct {
+	Completed int
+	Total     int
+	Results   map[string]string
+}
+
+// MaxLoadTestIPs caps LoadTestWorkflow's n. Every lookup is an activity in
+// a single run's history, which stays well inside Temporal's history limits
+// at this size.
+const MaxLoadTestIPs = 1000
+
+// LoadTestWorkflow looks up n random public IPs concurrently. Lookups that
+// fail are logged and left out of the result, since the point is to drive
+// load rather than to get every answer. Progress is available through
+// ProgressQuery while it runs. n must be between 1 and MaxLoadTestIPs.
+func LoadTestWorkflow(ctx workflow.Context, n int) ([]Data, error) {
+	if n <= 0 || n > MaxLoadTestIPs {
+		return nil, temporal.NewNonRetryableApplicationError(fmt.Sprintf("n = %d, want 1 to %d", n, MaxLoadTestIPs), ErrInvalidInput, nil)
+	}
+	ao := ActivityConfig{}.Options()
+	var ipActivities *IPActivities
+	ctx = workflow.WithActivityOptions(ctx, ao)
+
+	ips := RandomPublicIPs(ctx, n)
+	progress := BatchProgress{Total: len(ips), Results: make(map[string]string)}
+	err := workflow.SetQueryHandler(ctx, ProgressQuery, func() (BatchProgress, error) {
+		return progress, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Handle lookups in the order they finish so progress moves as soon as
+	// any of them does. The selector replays in the same order.
+	selector := workflow.NewSelector(ctx)
+	for _, ip := range ips {
+		f := workflow.ExecuteActivity(ctx, ipActivities.GetLocationInfo, ip)
+		selector.AddFuture(f, func(f workflow.Future) {
+			progress.Completed++
+			var location string
+			if err := f.Get(ctx, &location); err != nil {
+				workflow.GetLogger(ctx).Warn("Lookup failed", "ip", ip, "error", err)
+				return
+			}
+			progress.Results[ip] = location
+		})
+	}
+	for range ips {
+		selector.Select(ctx)
+	}
+
+	results := make([]Data, 0, len(progress.Results))
+	for _, ip := range ips {
+		if location, ok := progress.Results[ip]; ok {
+			results = append(results, Data{Result: ip, Location: location})
+		}
+	}
+	return results, nil
+}
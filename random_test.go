@@ -0,0 +1,142 @@
+package iplocate
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestIsReservedIPv4(t *testing.T) {
+	for ip, want := range map[string]bool{
+		"10.1.2.3":    true,
+		"100.64.0.1":  true,
+		"192.168.1.1": true,
+		"224.0.0.1":   true,
+		"8.8.8.8":     false,
+		"1.1.1.1":     false,
+	} {
+		if got := isReservedIPv4(net.ParseIP(ip)); got != want {
+			t.Errorf("isReservedIPv4(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
+
+func TestRandomPublicIPs(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflowWithOptions(func(ctx workflow.Context, n int) ([]string, error) {
+		return RandomPublicIPs(ctx, n), nil
+	}, workflow.RegisterOptions{Name: "random-ips"})
+
+	env.ExecuteWorkflow("random-ips", 500)
+	var ips []string
+	if err := env.GetWorkflowResult(&ips); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ips) != 500 {
+		t.Fatalf("got %d IPs, want 500", len(ips))
+	}
+	for _, s := range ips {
+		ip := net.ParseIP(s)
+		if ip == nil || ip.To4() == nil || isReservedIPv4(ip) {
+			t.Errorf("%q is not a public IPv4 address", s)
+		}
+	}
+}
+
+func TestLoadTestWorkflow(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+	env.OnActivity("GetLocationInfo", mock.Anything, mock.Anything).Return("City: Somewhere", nil)
+
+	env.ExecuteWorkflow(LoadTestWorkflow, 5)
+	var results []Data
+	if err := env.GetWorkflowResult(&results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 5 {
+		t.Errorf("got %d results, want 5", len(results))
+	}
+}
+
+func TestLoadTestWorkflow_Progress(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+	env.OnActivity("GetLocationInfo", mock.Anything, mock.Anything).
+		Return("City: Fast", nil).After(time.Minute).Times(2)
+	env.OnActivity("GetLocationInfo", mock.Anything, mock.Anything).
+		Return("City: Slow", nil).After(time.Hour)
+
+	env.RegisterDelayedCallback(func() {
+		encoded, err := env.QueryWorkflow(ProgressQuery)
+		if err != nil {
+			t.Errorf("progress query: %v", err)
+			return
+		}
+		var p BatchProgress
+		if err := encoded.Get(&p); err != nil {
+			t.Error(err)
+			return
+		}
+		if p.Completed != 2 || p.Total != 5 || len(p.Results) != 2 {
+			t.Errorf("progress mid-run = %+v, want 2 of 5 done", p)
+		}
+	}, 30*time.Minute)
+
+	env.ExecuteWorkflow(LoadTestWorkflow, 5)
+	var results []Data
+	if err := env.GetWorkflowResult(&results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 5 {
+		t.Errorf("got %d results, want 5", len(results))
+	}
+}
+
+func TestLoadTestWorkflow_RejectsInvalidN(t *testing.T) {
+	for _, n := range []int{-1, 0, MaxLoadTestIPs + 1} {
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestWorkflowEnvironment()
+		env.RegisterActivity(&IPActivities{})
+
+		env.ExecuteWorkflow(LoadTestWorkflow, n)
+		var appErr *temporal.ApplicationError
+		if err := env.GetWorkflowError(); !errors.As(err, &appErr) || appErr.Type() != ErrInvalidInput {
+			t.Errorf("n = %d: err = %v, want %s", n, err, ErrInvalidInput)
+		}
+	}
+}
+
+// repeatSource yields vals over and over.
+type repeatSource struct {
+	vals []int64
+	next int
+}
+
+func (s *repeatSource) Int63() int64 {
+	v := s.vals[s.next%len(s.vals)]
+	s.next++
+	return v
+}
+
+func (s *repeatSource) Seed(int64) {}
+
+func TestPublicIPs_Distinct(t *testing.T) {
+	// rand.Uint32 takes the top 32 of Int63's 63 bits.
+	eight := int64(0x08080808) << 31
+	one := int64(0x01010101) << 31
+	ips := publicIPs(rand.New(&repeatSource{vals: []int64{eight, eight, eight, one}}), 2)
+	if len(ips) != 2 || ips[0] != "8.8.8.8" || ips[1] != "1.1.1.1" {
+		t.Errorf("publicIPs = %v, want [8.8.8.8 1.1.1.1]", ips)
+	}
+}
@@ -0,0 +1,167 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/workflow"
+)
+
+// CacheTTL is how old a RecordResult entry gets before
+// CacheReconcileWorkflow looks its IP up again.
+const CacheTTL = 24 * time.Hour
+
+type cachedEntry struct {
+	encoded  string
+	storedAt time.Time
+}
+
+// CacheEntry is one result cached by RecordResult.
+type CacheEntry struct {
+	IP       string
+	Result   Data
+	StoredAt time.Time
+}
+
+// ReconcileReport counts what CacheReconcileWorkflow did with each entry.
+type ReconcileReport struct {
+	// Fresh entries were younger than CacheTTL and left alone.
+	Fresh     int
+	Unchanged int
+	Changed   int
+	// Removed entries were for IPs the provider will never locate.
+	Removed int
+	// Failed entries couldn't be looked up and were kept as they were.
+	Failed int
+}
+
+// ListCache returns up to limit results cached by RecordResult, in IP
+// order, starting after the IP after. Pass the last IP of one page as after
+// to get the next; entries deleted in between don't shift the pages.
+func (i *IPActivities) ListCache(ctx context.Context, after string, limit int) ([]CacheEntry, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ips := make([]string, 0, len(i.results))
+	for ip := range i.results {
+		if ip > after {
+			ips = append(ips, ip)
+		}
+	}
+	sort.Strings(ips)
+	if limit > 0 && len(ips) > limit {
+		ips = ips[:limit]
+	}
+
+	entries := make([]CacheEntry, 0, len(ips))
+	for _, ip := range ips {
+		cached := i.results[ip]
+		var result Data
+		if err := json.Unmarshal([]byte(cached.encoded), &result); err != nil {
+			activity.GetLogger(ctx).Warn("Skipping unreadable cache entry", "ip", ip, "error", err)
+			continue
+		}
+		entries = append(entries, CacheEntry{IP: ip, Result: result, StoredAt: cached.storedAt})
+	}
+	return entries, nil
+}
+
+// DeleteCachedResult drops the result cached for ip, if any.
+func (i *IPActivities) DeleteCachedResult(ctx context.Context, ip string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.results, ip)
+	return nil
+}
+
+// Each ListCache call returns reconcilePageSize entries, and a run
+// continues as new after reconcileEntriesPerRun, keeping both activity
+// results and history small however big the cache grows.
+const (
+	reconcilePageSize      = 100
+	reconcileEntriesPerRun = 500
+)
+
+// ReconcileProgress carries CacheReconcileWorkflow across continue-as-new.
+type ReconcileProgress struct {
+	// After is the last IP handled; the next run starts past it.
+	After  string
+	Report ReconcileReport
+}
+
+// CacheReconcileWorkflow looks up again every cached result older than
+// CacheTTL. Entries whose location changed are replaced, unchanged ones are
+// re-recorded so they count as fresh again, and entries for IPs the
+// provider rejects as reserved or invalid are deleted. It walks the cache a
+// page at a time and continues as new every reconcileEntriesPerRun
+// entries; start it with nil. The report it returns covers the whole walk.
+// Run it from a schedule; see starter/reconcile.
+//
+// The cache lives in the memory of each worker process, so with several
+// workers on the queue the activities of one run may land on different
+// caches. It is only exact with a single worker.
+func CacheReconcileWorkflow(ctx workflow.Context, resume *ReconcileProgress) (ReconcileReport, error) {
+	ctx = workflow.WithActivityOptions(ctx, ActivityConfig{}.Options())
+	var ipActivities *IPActivities
+	logger := workflow.GetLogger(ctx)
+
+	var progress ReconcileProgress
+	if resume != nil {
+		progress = *resume
+	}
+	report := &progress.Report
+	now := workflow.Now(ctx)
+
+	for handled := 0; handled < reconcileEntriesPerRun; {
+		var entries []CacheEntry
+		err := workflow.ExecuteActivity(ctx, ipActivities.ListCache, progress.After, reconcilePageSize).Get(ctx, &entries)
+		if err != nil {
+			return *report, err
+		}
+
+		for _, entry := range entries {
+			if err := reconcileEntry(ctx, entry, now, report); err != nil {
+				return *report, err
+			}
+			progress.After = entry.IP
+			handled++
+		}
+		if len(entries) < reconcilePageSize {
+			logger.Info("Cache reconciled", "report", *report)
+			return *report, nil
+		}
+	}
+	return *report, workflow.NewContinueAsNewError(ctx, CacheReconcileWorkflow, &progress)
+}
+
+func reconcileEntry(ctx workflow.Context, entry CacheEntry, now time.Time, report *ReconcileReport) error {
+	var ipActivities *IPActivities
+	if now.Sub(entry.StoredAt) < CacheTTL {
+		report.Fresh++
+		return nil
+	}
+
+	var location string
+	err := workflow.ExecuteActivity(ctx, ipActivities.GetLocationInfo, entry.IP).Get(ctx, &location)
+	switch {
+	case err != nil && ErrorCategoryOf(err) == CategoryValidation:
+		if err := workflow.ExecuteActivity(ctx, ipActivities.DeleteCachedResult, entry.IP).Get(ctx, nil); err != nil {
+			return err
+		}
+		report.Removed++
+		return nil
+	case err != nil:
+		workflow.GetLogger(ctx).Warn("Failed to revalidate cache entry", "ip", entry.IP, "error", err)
+		report.Failed++
+		return nil
+	case location == entry.Result.Location:
+		report.Unchanged++
+	default:
+		entry.Result.Location = location
+		report.Changed++
+	}
+	return workflow.ExecuteActivity(ctx, ipActivities.RecordResult, entry.IP, entry.Result).Get(ctx, nil)
+}
@@ -0,0 +1,101 @@
+package iplocate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func TestCacheReconcileWorkflow(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	stale := now.Add(-2 * CacheTTL)
+	a := &IPActivities{
+		HTTPClient: &stubGetter{bodies: map[string]string{
+			"http://ip-api.com/json/8.8.8.8":  `{"status":"success","city":"Mountain View","regionName":"California","country":"United States"}`,
+			"http://ip-api.com/json/1.1.1.1":  `{"status":"success","city":"Sydney","regionName":"New South Wales","country":"Australia"}`,
+			"http://ip-api.com/json/10.0.0.1": `{"status":"fail","message":"private range"}`,
+		}},
+		results: make(map[string]cachedEntry),
+	}
+	cache := func(ip, location string, storedAt time.Time) {
+		encoded, _ := json.Marshal(Data{Result: ip, Location: location})
+		a.results[ip] = cachedEntry{encoded: string(encoded), storedAt: storedAt}
+	}
+	cache("8.8.8.8", "City: Mountain View, Region: California, Country: United States", stale)
+	cache("1.1.1.1", "City: Brisbane, Region: Queensland, Country: Australia", stale)
+	cache("10.0.0.1", "City: , Region: , Country: ", stale)
+	cache("9.9.9.9", "City: Zurich, Region: Zurich, Country: Switzerland", now.Add(-time.Hour))
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.SetStartTime(now)
+	env.RegisterActivity(a)
+	env.ExecuteWorkflow(CacheReconcileWorkflow, (*ReconcileProgress)(nil))
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatal(err)
+	}
+	var report ReconcileReport
+	if err := env.GetWorkflowResult(&report); err != nil {
+		t.Fatal(err)
+	}
+	want := ReconcileReport{Fresh: 1, Unchanged: 1, Changed: 1, Removed: 1}
+	if report != want {
+		t.Errorf("report = %+v, want %+v", report, want)
+	}
+
+	if _, ok := a.cachedResult("10.0.0.1"); ok {
+		t.Error("private range entry was not removed")
+	}
+	if got, _ := a.cachedResult("1.1.1.1"); got.Location != "City: Sydney, Region: New South Wales, Country: Australia" {
+		t.Errorf("1.1.1.1 location = %q, want the fresh lookup", got.Location)
+	}
+	if a.results["8.8.8.8"].storedAt.Equal(stale) {
+		t.Error("unchanged entry was not refreshed")
+	}
+}
+
+func TestCacheReconcileWorkflow_ContinuesAsNew(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	a := &IPActivities{results: make(map[string]cachedEntry)}
+	for n := 0; n <= reconcileEntriesPerRun; n++ {
+		ip := fmt.Sprintf("8.8.%d.%d", n/256, n%256)
+		a.results[ip] = cachedEntry{encoded: `{"Result":"` + ip + `"}`, storedAt: now}
+	}
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.SetStartTime(now)
+	env.RegisterActivity(a)
+	env.ExecuteWorkflow(CacheReconcileWorkflow, (*ReconcileProgress)(nil))
+
+	var can *workflow.ContinueAsNewError
+	if err := env.GetWorkflowError(); !errors.As(err, &can) {
+		t.Fatalf("err = %v, want ContinueAsNewError", err)
+	}
+	var progress *ReconcileProgress
+	if err := converter.GetDefaultDataConverter().FromPayloads(can.Input, &progress); err != nil {
+		t.Fatal(err)
+	}
+	if progress.Report.Fresh != reconcileEntriesPerRun {
+		t.Fatalf("first run report = %+v, want %d fresh", progress.Report, reconcileEntriesPerRun)
+	}
+
+	env = suite.NewTestWorkflowEnvironment()
+	env.SetStartTime(now)
+	env.RegisterActivity(a)
+	env.ExecuteWorkflow(CacheReconcileWorkflow, progress)
+	var report ReconcileReport
+	if err := env.GetWorkflowResult(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Fresh != reconcileEntriesPerRun+1 {
+		t.Errorf("final report = %+v, want %d fresh", report, reconcileEntriesPerRun+1)
+	}
+}
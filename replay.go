@@ -0,0 +1,125 @@
+package iplocate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/api/temporalproto"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+)
+
+// RegisterWorkflows registers every workflow in the package. The replay
+// tests use it so a new workflow can't be left out of them; a new workflow
+// also belongs in exactly one of RegisterLookupWorkflows and
+// RegisterMonitorWorkflows.
+func RegisterWorkflows(r worker.WorkflowRegistry) {
+	RegisterLookupWorkflows(r)
+	RegisterMonitorWorkflows(r)
+}
+
+// RegisterLookupWorkflows registers the workflows served on
+// TaskQueueLookup.
+func RegisterLookupWorkflows(r worker.WorkflowRegistry) {
+	r.RegisterWorkflow(GetAddressFromIP)
+	r.RegisterWorkflow(GetAddressFromIPV2)
+	r.RegisterWorkflow(OnDemandLookupWorkflow)
+	r.RegisterWorkflow(ProviderComparisonWorkflow)
+	r.RegisterWorkflow(LoadTestWorkflow)
+	r.RegisterWorkflow(BackfillWorkflow)
+	r.RegisterWorkflow(ShardedLookupWorkflow)
+	r.RegisterWorkflow(BlocklistCheckWorkflow)
+	r.RegisterWorkflow(GetAddressWithWeatherWorkflow)
+	r.RegisterWorkflow(CacheReconcileWorkflow)
+}
+
+// RegisterMonitorWorkflows registers the long-running workflows that watch
+// an IP over time. They are served on TaskQueueMonitor; start them there.
+func RegisterMonitorWorkflows(r worker.WorkflowRegistry) {
+	r.RegisterWorkflow(WaitForLocationWorkflow)
+	r.RegisterWorkflow(GeofenceWorkflow)
+	r.RegisterWorkflow(HourlyReportWorkflow)
+}
+
+// ExportHistory writes the full event history of a workflow run as JSON, in
+// the format expected by WorkflowReplayer.ReplayWorkflowHistoryFromJSONFile.
+// Use it to add fixtures under testdata/ from a real run.
+func ExportHistory(ctx context.Context, c client.Client, workflowID, runID string, w io.Writer) error {
+	hist := &historypb.History{}
+	iter := c.GetWorkflowHistory(ctx, workflowID, runID, false, 0)
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			return err
+		}
+		hist.Events = append(hist.Events, event)
+	}
+
+	bs, err := temporalproto.CustomJSONMarshalOptions{Indent: "  "}.Marshal(hist)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(bs, '\n'))
+	return err
+}
+
+// ExportHistoryNDJSON writes the events of a workflow run as newline
+// delimited JSON, one event per line, as they are fetched, for log pipelines
+// that ingest line by line. Unlike ExportHistory's output it can't be
+// replayed.
+func ExportHistoryNDJSON(ctx context.Context, c client.Client, workflowID, runID string, w io.Writer) error {
+	iter := c.GetWorkflowHistory(ctx, workflowID, runID, false, 0)
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			return err
+		}
+		bs, err := temporalproto.CustomJSONMarshalOptions{}.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(bs, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplayHistoryFile replays a history written by ExportHistory against the
+// current workflow code and returns the nondeterminism error, if any. When
+// workflowType is set, the history must be of that type. Like Dial, it
+// decrypts payloads when EncryptionKeyEnv is set.
+func ReplayHistoryFile(path, workflowType string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hist, err := client.HistoryFromJSON(f, client.HistoryJSONOptions{})
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(hist.Events) == 0 {
+		return fmt.Errorf("%s has no events", path)
+	}
+	got := hist.Events[0].GetWorkflowExecutionStartedEventAttributes().GetWorkflowType().GetName()
+	if workflowType != "" && got != workflowType {
+		return fmt.Errorf("%s is a %s history, not %s", path, got, workflowType)
+	}
+
+	// Histories from encrypted deployments need the key to decode inputs.
+	var opts worker.WorkflowReplayerOptions
+	if key := os.Getenv(EncryptionKeyEnv); key != "" {
+		opts.DataConverter = NewEncryptedDataConverter(StaticKeyProvider{ID: "env", Secret: []byte(key)})
+	}
+	replayer, err := worker.NewWorkflowReplayerWithOptions(opts)
+	if err != nil {
+		return err
+	}
+	RegisterWorkflows(replayer)
+	return replayer.ReplayWorkflowHistory(nil, hist)
+}
@@ -0,0 +1,77 @@
+package iplocate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/sdk/worker"
+)
+
+func TestReplayHistories(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no histories found in testdata")
+	}
+
+	replayer := worker.NewWorkflowReplayer()
+	RegisterWorkflows(replayer)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			if err := replayer.ReplayWorkflowHistoryFromJSONFile(nil, file); err != nil {
+				t.Fatalf("replay failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestReplayHistoryFile(t *testing.T) {
+	file := filepath.Join("testdata", "get_address_from_ip_v2.json")
+	if err := ReplayHistoryFile(file, "GetAddressFromIPV2"); err != nil {
+		t.Errorf("replay failed: %v", err)
+	}
+	if err := ReplayHistoryFile(file, "GetAddressFromIP"); err == nil {
+		t.Error("expected a workflow type mismatch error")
+	}
+}
+
+func TestExportHistoryNDJSON(t *testing.T) {
+	iter := &mocks.HistoryEventIterator{}
+	for id := int64(1); id <= 3; id++ {
+		iter.On("HasNext").Return(true).Once()
+		iter.On("Next").Return(&historypb.HistoryEvent{EventId: id}, nil).Once()
+	}
+	iter.On("HasNext").Return(false)
+	c := &mocks.Client{}
+	c.On("GetWorkflowHistory", mock.Anything, "wf", "", false, mock.Anything).Return(iter)
+
+	var buf bytes.Buffer
+	if err := ExportHistoryNDJSON(context.Background(), c, "wf", "", &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var event struct {
+			EventID string `json:"eventId"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("line %q is not JSON: %v", scanner.Text(), err)
+		}
+		ids = append(ids, event.EventID)
+	}
+	if len(ids) != 3 || ids[0] != "1" || ids[1] != "2" || ids[2] != "3" {
+		t.Errorf("event ids = %v, want [1 2 3]", ids)
+	}
+}
@@ -0,0 +1,57 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ReverseGeocode resolves coordinates to a place with BigDataCloud's free
+// client endpoint. Coordinates outside any country, such as open ocean,
+// return LocationDetails with only Lat and Lon set rather than an error.
+func (i *IPActivities) ReverseGeocode(ctx context.Context, lat, lon float64) (LocationDetails, error) {
+	url := fmt.Sprintf("https://api.bigdatacloud.net/data/reverse-geocode-client?latitude=%g&longitude=%g&localityLanguage=en", lat, lon)
+
+	resp, err := i.get(ctx, url)
+	if err != nil {
+		return LocationDetails{}, lookupError(CategoryNetwork, "HTTP GET", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return LocationDetails{}, lookupError(CategoryNetwork, "read body", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return LocationDetails{}, lookupError(CategoryProviderFail, "bigdatacloud", fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	var data struct {
+		City          string `json:"city"`
+		Locality      string `json:"locality"`
+		Subdivision   string `json:"principalSubdivision"`
+		Country       string `json:"countryName"`
+		CountryCode   string `json:"countryCode"`
+		Continent     string `json:"continent"`
+		ContinentCode string `json:"continentCode"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return LocationDetails{}, lookupError(CategoryParse, "JSON unmarshal", err)
+	}
+
+	details := LocationDetails{Lat: lat, Lon: lon}
+	if data.CountryCode == "" {
+		return details, nil
+	}
+	details.City = data.City
+	if data.Locality != data.City {
+		details.District = data.Locality
+	}
+	details.Region = data.Subdivision
+	details.Country = data.Country
+	details.CountryCode = data.CountryCode
+	details.Continent = data.Continent
+	details.ContinentCode = data.ContinentCode
+	return details, nil
+}
@@ -0,0 +1,47 @@
+package iplocate
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIPActivities_ReverseGeocode(t *testing.T) {
+	a := &IPActivities{HTTPClient: &stubGetter{bodies: map[string]string{
+		"https://api.bigdatacloud.net/data/reverse-geocode-client?latitude=51.5007&longitude=-0.1246&localityLanguage=en": `{
+			"latitude": 51.5007, "longitude": -0.1246, "continent": "Europe", "continentCode": "EU",
+			"countryName": "United Kingdom of Great Britain and Northern Ireland", "countryCode": "GB",
+			"principalSubdivision": "England", "city": "London", "locality": "Westminster"
+		}`,
+		"https://api.bigdatacloud.net/data/reverse-geocode-client?latitude=-30&longitude=-20&localityLanguage=en": `{
+			"latitude": -30, "longitude": -20, "continent": "", "countryName": "", "countryCode": "",
+			"principalSubdivision": "", "city": "", "locality": "South Atlantic Ocean"
+		}`,
+	}}}
+
+	got, err := a.ReverseGeocode(context.Background(), 51.5007, -0.1246)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LocationDetails{
+		City:          "London",
+		District:      "Westminster",
+		Region:        "England",
+		Country:       "United Kingdom of Great Britain and Northern Ireland",
+		CountryCode:   "GB",
+		Continent:     "Europe",
+		ContinentCode: "EU",
+		Lat:           51.5007,
+		Lon:           -0.1246,
+	}
+	if got != want {
+		t.Errorf("ReverseGeocode = %+v, want %+v", got, want)
+	}
+
+	got, err = a.ReverseGeocode(context.Background(), -30, -20)
+	if err != nil {
+		t.Fatalf("ocean: %v", err)
+	}
+	if want := (LocationDetails{Lat: -30, Lon: -20}); got != want {
+		t.Errorf("ocean: ReverseGeocode = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package iplocate
+
+import (
+	"fmt"
+	"strings"
+
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/workflow"
+)
+
+// TaskQueueMaxMind is the activity queue polled by workers holding a
+// MaxMind database.
+const TaskQueueMaxMind = "ip-finder-maxmind"
+
+// RoutingInterceptor sends the activities of every workflow on a worker to
+// dedicated task queues, so they run only on workers that can serve them,
+// such as those holding a MaxMind database. Add it to
+// worker.Options.Interceptors.
+//
+// Routes are applied when a workflow schedules an activity, so every worker
+// running workflows must use the same routes. A worker polling a routed
+// queue must register IPActivities, with the routed provider in Providers,
+// on that queue; with nothing polling it the activity waits until its
+// timeout.
+type RoutingInterceptor struct {
+	interceptor.WorkerInterceptorBase
+	// Routes keys are activity types, or "ProviderLookup/<provider>" to
+	// route one provider's lookups; values are task queues. Unrouted
+	// activities run on the workflow's own queue.
+	Routes map[string]string
+}
+
+type routesKey struct{}
+
+func (r *RoutingInterceptor) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	return &routingWorkflowInterceptor{
+		WorkflowInboundInterceptorBase: interceptor.WorkflowInboundInterceptorBase{Next: next},
+		routes:                         r.Routes,
+	}
+}
+
+type routingWorkflowInterceptor struct {
+	interceptor.WorkflowInboundInterceptorBase
+	routes map[string]string
+}
+
+func (w *routingWorkflowInterceptor) ExecuteWorkflow(ctx workflow.Context, in *interceptor.ExecuteWorkflowInput) (any, error) {
+	return w.Next.ExecuteWorkflow(workflow.WithValue(ctx, routesKey{}, w.routes), in)
+}
+
+// ParseActivityRoutes parses "key=queue,key=queue", the format of the
+// worker's ACTIVITY_ROUTES variable.
+func ParseActivityRoutes(s string) (map[string]string, error) {
+	routes := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, queue, ok := strings.Cut(entry, "=")
+		key, queue = strings.TrimSpace(key), strings.TrimSpace(queue)
+		if !ok || key == "" || queue == "" {
+			return nil, fmt.Errorf("activity route %q must look like type=queue", entry)
+		}
+		routes[key] = queue
+	}
+	return routes, nil
+}
+
+// withRoute applies the first of keys found in the RoutingInterceptor's
+// routes to ctx.
+// Task queues aren't compared on replay, so changing routes is safe for
+// running workflows.
+func withRoute(ctx workflow.Context, keys ...string) workflow.Context {
+	routes, _ := ctx.Value(routesKey{}).(map[string]string)
+	for _, key := range keys {
+		if queue, ok := routes[key]; ok {
+			return workflow.WithTaskQueue(ctx, queue)
+		}
+	}
+	return ctx
+}
+
+// executeProviderLookup schedules ProviderLookup for provider, routed by
+// the worker's RoutingInterceptor.
+func executeProviderLookup(ctx workflow.Context, provider, ip string) workflow.Future {
+	var ipActivities *IPActivities
+	ctx = withRoute(ctx, "ProviderLookup/"+provider, "ProviderLookup")
+	return workflow.ExecuteActivity(ctx, ipActivities.ProviderLookup, provider, ip)
+}
@@ -0,0 +1,57 @@
+package iplocate
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+)
+
+func TestRoutingInterceptor(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.SetWorkerOptions(worker.Options{Interceptors: []interceptor.WorkerInterceptor{
+		&RoutingInterceptor{Routes: map[string]string{"ProviderLookup/" + SecondaryProvider: "geo-heavy"}},
+	}})
+	env.RegisterActivity(&IPActivities{})
+
+	queues := make(map[string]string)
+	env.OnActivity("ProviderLookup", mock.Anything, mock.Anything, "8.8.8.8").Return(
+		func(ctx context.Context, name, ip string) (LocationDetails, error) {
+			queues[name] = activity.GetInfo(ctx).TaskQueue
+			return LocationDetails{IP: ip, City: "Ashburn", Country: "United States"}, nil
+		})
+
+	env.ExecuteWorkflow(ProviderComparisonWorkflow, "8.8.8.8")
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+
+	workflowQueue := "default-test-taskqueue"
+	want := map[string]string{PrimaryProvider: workflowQueue, SecondaryProvider: "geo-heavy"}
+	if !reflect.DeepEqual(queues, want) {
+		t.Errorf("task queues = %v, want %v", queues, want)
+	}
+}
+
+func TestParseActivityRoutes(t *testing.T) {
+	got, err := ParseActivityRoutes(" ProviderLookup/primary = ip-finder-maxmind, GetLocationBulk=bulk,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"ProviderLookup/primary": "ip-finder-maxmind", "GetLocationBulk": "bulk"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("routes = %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"ProviderLookup", "=queue", "GetIP="} {
+		if _, err := ParseActivityRoutes(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
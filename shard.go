@@ -0,0 +1,78 @@
+package iplocate
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"strconv"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ShardForIP maps ip to one of shards buckets with a 32-bit FNV-1a hash of
+// its canonical form, so "8.8.8.8" and "::ffff:8.8.8.8" agree. It is pure
+// and safe to call from workflow code.
+func ShardForIP(ip string, shards int) int {
+	if shards <= 1 {
+		return 0
+	}
+	if parsed := net.ParseIP(ip); parsed != nil {
+		ip = parsed.String()
+	}
+	h := fnv.New32a()
+	h.Write([]byte(ip))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// ShardTaskQueue is the task queue for one shard of TaskQueueLookup. Run a
+// worker per shard with WORKER_SHARD set.
+func ShardTaskQueue(shard int) string {
+	return TaskQueueLookup + "-" + strconv.Itoa(shard)
+}
+
+// shardQueue is the task queue ShardedLookupWorkflow uses for ip. A
+// single shard needs no shard workers, so it stays on TaskQueueLookup.
+func shardQueue(ip string, shards int) string {
+	if shards == 1 {
+		return TaskQueueLookup
+	}
+	return ShardTaskQueue(ShardForIP(ip, shards))
+}
+
+// ShardedLookupWorkflow runs a GetAddressFromIP child per IP on the task
+// queue of the IP's shard and returns their results in input order. With
+// more than one shard, every shard needs a worker started with
+// WORKER_SHARD. A failed child fails the workflow.
+func ShardedLookupWorkflow(ctx workflow.Context, ips []string, shards int) ([]Data, error) {
+	if shards < 1 {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("shards = %d, want at least 1", shards), ErrInvalidInput, nil)
+	}
+	for k, ip := range ips {
+		if net.ParseIP(ip) == nil {
+			return nil, temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("ip %d: invalid ip %q", k, ip), ErrInvalidInput, nil)
+		}
+	}
+
+	futures := make([]workflow.ChildWorkflowFuture, len(ips))
+	for k, ip := range ips {
+		cctx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+			TaskQueue:                shardQueue(ip, shards),
+			WorkflowExecutionTimeout: 10 * time.Minute,
+		})
+		futures[k] = workflow.ExecuteChildWorkflow(cctx, GetAddressFromIP, "", LookupOptions{IP: ip, DemoSleep: NoDemoSleep})
+	}
+
+	results := make([]Data, len(ips))
+	for k, f := range futures {
+		var location string
+		if err := f.Get(ctx, &location); err != nil {
+			return nil, err
+		}
+		results[k] = Data{Result: ips[k], Location: location}
+	}
+	return results, nil
+}
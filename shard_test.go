@@ -0,0 +1,88 @@
+package iplocate
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestShardForIP(t *testing.T) {
+	if a, b := ShardForIP("8.8.8.8", 8), ShardForIP("8.8.8.8", 8); a != b {
+		t.Errorf("same IP hashed to %d and %d", a, b)
+	}
+	if a, b := ShardForIP("8.8.8.8", 8), ShardForIP("::ffff:8.8.8.8", 8); a != b {
+		t.Errorf("equivalent forms hashed to %d and %d", a, b)
+	}
+	if got := ShardForIP("8.8.8.8", 1); got != 0 {
+		t.Errorf("single shard = %d, want 0", got)
+	}
+
+	const shards, n = 8, 8000
+	counts := make([]int, shards)
+	for k := 0; k < n; k++ {
+		s := ShardForIP(fmt.Sprintf("%d.%d.%d.%d", 1+k%200, k/200, k%7, k%251), shards)
+		if s < 0 || s >= shards {
+			t.Fatalf("shard %d out of range", s)
+		}
+		counts[s]++
+	}
+	for s, c := range counts {
+		// Expect about 1000 each; allow 20% either way.
+		if c < 800 || c > 1200 {
+			t.Errorf("shard %d got %d of %d IPs: %v", s, c, n, counts)
+		}
+	}
+}
+
+func TestShardedLookupWorkflow(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(GetAddressFromIP)
+	env.RegisterActivity(&IPActivities{})
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("City: Mountain View", nil)
+	env.OnActivity("GetLocationInfo", mock.Anything, "1.1.1.1").Return("City: Sydney", nil)
+
+	env.ExecuteWorkflow(ShardedLookupWorkflow, []string{"8.8.8.8", "1.1.1.1"}, 4)
+	var got []Data
+	if err := env.GetWorkflowResult(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Location != "City: Mountain View" || got[1].Location != "City: Sydney" {
+		t.Errorf("results = %+v", got)
+	}
+}
+
+func TestShardQueue(t *testing.T) {
+	if got := shardQueue("8.8.8.8", 1); got != TaskQueueLookup {
+		t.Errorf("single shard queue = %q, want %q", got, TaskQueueLookup)
+	}
+	if got, want := shardQueue("8.8.8.8", 4), ShardTaskQueue(ShardForIP("8.8.8.8", 4)); got != want {
+		t.Errorf("queue = %q, want %q", got, want)
+	}
+}
+
+func TestShardedLookupWorkflow_RejectsInvalidInput(t *testing.T) {
+	cases := map[string]struct {
+		ips    []string
+		shards int
+	}{
+		"zero shards":     {[]string{"8.8.8.8"}, 0},
+		"negative shards": {[]string{"8.8.8.8"}, -2},
+		"invalid ip":      {[]string{"8.8.8.8", "not-an-ip"}, 4},
+	}
+	for name, c := range cases {
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestWorkflowEnvironment()
+		env.RegisterWorkflow(GetAddressFromIP)
+
+		env.ExecuteWorkflow(ShardedLookupWorkflow, c.ips, c.shards)
+		var appErr *temporal.ApplicationError
+		if err := env.GetWorkflowError(); !errors.As(err, &appErr) || appErr.Type() != ErrInvalidInput {
+			t.Errorf("%s: err = %v, want %s", name, err, ErrInvalidInput)
+		}
+	}
+}
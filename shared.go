@@ -1,3 +1,83 @@
 package iplocate
 
-const TaskQueueName = "ip-finder"
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Workflows are split across task queues by how they use a worker: lookups
+// are short and bursty, while long-running monitors mostly sit on timers.
+// The worker polls each queue with its own task slots, so a burst of
+// lookups can't starve monitors. See RegisterLookupWorkflows and
+// RegisterMonitorWorkflows for which workflow runs where.
+const (
+	TaskQueueLookup  = "ip-finder"
+	TaskQueueMonitor = "ip-monitor"
+
+	// TaskQueueName is kept for existing callers.
+	TaskQueueName = TaskQueueLookup
+)
+
+// DefaultActivityTimeout is the StartToCloseTimeout of every activity in the
+// package unless an ActivityConfig says otherwise.
+const DefaultActivityTimeout = time.Minute
+
+// ActivityConfig is the one place workflows get their activity options
+// from, so timeouts and retries are tuned here rather than per workflow.
+// The zero value gives DefaultActivityTimeout and DefaultRetryPolicy.
+type ActivityConfig struct {
+	StartToCloseTimeout time.Duration
+	// MaximumAttempts replaces DefaultRetryPolicy's when positive, for
+	// activities that shouldn't hold a workflow up for the full schedule.
+	MaximumAttempts int32
+	// HeartbeatTimeout makes the activities heartbeat while their HTTP
+	// requests are in flight, so that cancelling the workflow interrupts
+	// them. Zero means no heartbeats.
+	HeartbeatTimeout time.Duration
+}
+
+// LookupHeartbeatTimeout is the HeartbeatTimeout of the lookup workflows'
+// activities. A cancel reaches a running activity within about this long.
+const LookupHeartbeatTimeout = 10 * time.Second
+
+func (c ActivityConfig) Options() workflow.ActivityOptions {
+	ao := workflow.ActivityOptions{
+		StartToCloseTimeout: c.StartToCloseTimeout,
+		HeartbeatTimeout:    c.HeartbeatTimeout,
+		RetryPolicy:         DefaultRetryPolicy(),
+	}
+	if ao.StartToCloseTimeout <= 0 {
+		ao.StartToCloseTimeout = DefaultActivityTimeout
+	}
+	if c.MaximumAttempts > 0 {
+		ao.RetryPolicy.MaximumAttempts = c.MaximumAttempts
+	}
+	return ao
+}
+
+// DefaultRetryPolicy is the retry policy for every activity in the package.
+//
+// Retries start after 1s and double up to a 1m ceiling, so a lookup that
+// keeps failing is attempted at roughly 1s, 2s, 4s, ... 32s, 1m, 1m, ...
+// and gives up after 10 attempts (about 5 minutes) rather than retrying
+// forever. Reserved/private ranges and malformed IPs fail on the first
+// attempt since ip-api will never answer them differently.
+//
+// The policy cannot jitter: temporal.RetryPolicy has no jitter field and
+// the delays it produces are exactly the ones above, so activities that
+// fail together retry together. Workflows that need spread-out retries
+// loop themselves and sleep for Backoff, which jitters per run.
+func DefaultRetryPolicy() *temporal.RetryPolicy {
+	return &temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 2,
+		MaximumInterval:    time.Minute,
+		MaximumAttempts:    10,
+		NonRetryableErrorTypes: []string{
+			ErrReservedRange,
+			ErrInvalidQuery,
+		},
+	}
+}
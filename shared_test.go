@@ -0,0 +1,29 @@
+package iplocate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActivityConfigOptions(t *testing.T) {
+	ao := ActivityConfig{}.Options()
+	if ao.StartToCloseTimeout != DefaultActivityTimeout {
+		t.Errorf("StartToCloseTimeout = %s, want %s", ao.StartToCloseTimeout, DefaultActivityTimeout)
+	}
+	want := DefaultRetryPolicy()
+	if got := ao.RetryPolicy; got.InitialInterval != want.InitialInterval ||
+		got.MaximumInterval != want.MaximumInterval ||
+		got.MaximumAttempts != want.MaximumAttempts ||
+		len(got.NonRetryableErrorTypes) != len(want.NonRetryableErrorTypes) {
+		t.Errorf("RetryPolicy = %+v, want %+v", got, want)
+	}
+
+	if ao.HeartbeatTimeout != 0 {
+		t.Errorf("HeartbeatTimeout = %s, want none", ao.HeartbeatTimeout)
+	}
+
+	ao = ActivityConfig{StartToCloseTimeout: 5 * time.Second, MaximumAttempts: 3, HeartbeatTimeout: LookupHeartbeatTimeout}.Options()
+	if ao.StartToCloseTimeout != 5*time.Second || ao.RetryPolicy.MaximumAttempts != 3 || ao.HeartbeatTimeout != LookupHeartbeatTimeout {
+		t.Errorf("overrides not applied: %+v, %+v", ao, ao.RetryPolicy)
+	}
+}
@@ -0,0 +1,49 @@
+package iplocate
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ErrSLAExceeded is the application error type WithSLA fails with when the
+// lookup outlives its deadline.
+const ErrSLAExceeded = "SLAExceeded"
+
+// WithSLA runs fn and fails with ErrSLAExceeded if it hasn't returned
+// within deadline. fn gets a context that is cancelled when the deadline
+// passes, so activities and timers it started are cancelled with it; fn
+// must return once that happens. A non-positive deadline runs fn without
+// one.
+func WithSLA(ctx workflow.Context, deadline time.Duration, fn func(workflow.Context) (Data, error)) (Data, error) {
+	if deadline <= 0 {
+		return fn(ctx)
+	}
+
+	slaCtx, cancel := workflow.WithCancel(ctx)
+	defer cancel()
+
+	inner, settle := workflow.NewFuture(slaCtx)
+	workflow.Go(slaCtx, func(ctx workflow.Context) {
+		settle.Set(fn(ctx))
+	})
+
+	timedOut := false
+	selector := workflow.NewSelector(ctx)
+	selector.AddFuture(inner, func(workflow.Future) {})
+	selector.AddFuture(workflow.NewTimer(slaCtx, deadline), func(workflow.Future) { timedOut = true })
+	selector.Select(ctx)
+
+	if timedOut {
+		cancel()
+		// Let fn unwind so its cancellations are issued before we return.
+		_ = inner.Get(ctx, nil)
+		return Data{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("lookup did not finish within %s", deadline), ErrSLAExceeded, nil)
+	}
+	var result Data
+	err := inner.Get(ctx, &result)
+	return result, err
+}
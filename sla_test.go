@@ -0,0 +1,71 @@
+package iplocate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+func slaLookupWorkflow(ctx workflow.Context, ip string, deadline time.Duration) (Data, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Hour})
+	var ipActivities *IPActivities
+	return WithSLA(ctx, deadline, func(ctx workflow.Context) (Data, error) {
+		var location string
+		err := workflow.ExecuteActivity(ctx, ipActivities.GetLocationInfo, ip).Get(ctx, &location)
+		return Data{Result: ip, Location: location}, err
+	})
+}
+
+func TestWithSLA(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+	env.RegisterWorkflow(slaLookupWorkflow)
+
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").After(time.Minute).Return("City: Ashburn", nil)
+
+	env.ExecuteWorkflow(slaLookupWorkflow, "8.8.8.8", 5*time.Minute)
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	var got Data
+	if err := env.GetWorkflowResult(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Location != "City: Ashburn" {
+		t.Errorf("result = %+v", got)
+	}
+}
+
+func TestWithSLA_Exceeded(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+	env.RegisterWorkflow(slaLookupWorkflow)
+
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").After(10*time.Minute).Return("City: Ashburn", nil)
+	canceled := false
+	env.SetOnActivityCanceledListener(func(info *activity.Info) {
+		canceled = info.ActivityType.Name == "GetLocationInfo"
+	})
+
+	start := env.Now()
+	env.ExecuteWorkflow(slaLookupWorkflow, "8.8.8.8", 5*time.Minute)
+
+	var appErr *temporal.ApplicationError
+	if err := env.GetWorkflowError(); !errors.As(err, &appErr) || appErr.Type() != ErrSLAExceeded {
+		t.Fatalf("err = %v, want %s", err, ErrSLAExceeded)
+	}
+	if elapsed := env.Now().Sub(start); elapsed != 5*time.Minute {
+		t.Errorf("elapsed = %s, want the 5m deadline", elapsed)
+	}
+	if !canceled {
+		t.Error("GetLocationInfo was not cancelled")
+	}
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"context"
-	"fmt"
+	"encoding/json"
+	"flag"
 	"log"
+	"os"
 	"temporal-ip-geolocation/iplocate"
-	"time"
 
 	"go.temporal.io/sdk/client"
 	"google.golang.org/grpc"
@@ -13,8 +15,49 @@ import (
 )
 
 func main() {
+	dryRun := flag.Bool("dry-run", false, "validate inputs and print the start options without contacting Temporal")
+	timeout := flag.Duration("timeout", iplocate.DefaultClientTimeout, "deadline for each call to the Temporal server")
+	wait := flag.Bool("wait", false, "block until the workflow completes and log its result")
+	out := flag.String("out", "", "write the workflow result as JSON to this file, or - for stdout (implies -wait)")
+	waitTimeout := flag.Duration("wait-timeout", 0, "give up waiting for the result after this long; zero waits forever")
+	cancelOnTimeout := flag.Bool("cancel-on-timeout", false, "cancel the workflow when -wait-timeout expires instead of leaving it running")
+	flag.Parse()
+
+	// Workflow ID strategy:
+	// - Unique IDs (timestamp/UUID): Each execution is independent
+	// - Constant IDs: Ensures idempotency, prevents duplicate executions
+	// - Entity-based IDs: One workflow per business entity (e.g., "user-123")
+	//
+	// For this example, we use a random UUID for unique executions.
+	// In production, consider: "ip-lookup-" + requestID for idempotency
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        iplocate.WorkflowID("ip-geolocation-workflow", nil),
+		TaskQueue: iplocate.TaskQueueLookup,
+		// StartDelay: 10 * time.Second,
+	}
+
+	lookupOptions := iplocate.LookupOptions{}
+	if !iplocate.DemoMode {
+		lookupOptions.DemoSleep = iplocate.NoDemoSleep
+	}
+
+	if err := iplocate.ValidateStartOptions(workflowOptions); err != nil {
+		log.Fatalln("Invalid workflow options:", err)
+	}
+	if err := lookupOptions.Validate(); err != nil {
+		log.Fatalln("Invalid lookup options:", err)
+	}
+	if *dryRun {
+		log.Printf("Dry run, not starting workflow. Options: %+v\n", workflowOptions)
+		return
+	}
+
 	// Connect to Temporal server
-	c, err := client.Dial(client.Options{
+	dialAttempts, err := iplocate.DialAttemptsFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	c, err := iplocate.DialWithRetry(client.Options{
 		HostPort:  "127.0.0.1:7233",
 		Namespace: "default",
 		ConnectionOptions: client.ConnectionOptions{
@@ -23,26 +66,17 @@ func main() {
 				grpc.WithTransportCredentials(insecure.NewCredentials()),
 			},
 		},
-	})
+	}, dialAttempts)
 	if err != nil {
 		log.Fatalln("Unable to create client", err)
 	}
 	defer c.Close()
 
-	// Workflow ID strategy:
-	// - Unique IDs (timestamp/UUID): Each execution is independent
-	// - Constant IDs: Ensures idempotency, prevents duplicate executions
-	// - Entity-based IDs: One workflow per business entity (e.g., "user-123")
-	//
-	// For this example, we use timestamp for unique executions.
-	// In production, consider: "ip-lookup-" + requestID for idempotency
-	workflowOptions := client.StartWorkflowOptions{
-		ID:        "ip-geolocation-workflow-" + fmt.Sprint(time.Now().Unix()),
-		TaskQueue: iplocate.TaskQueueName,
-		// StartDelay: 10 * time.Second,
-	}
-
-	we, err := c.ExecuteWorkflow(context.Background(), workflowOptions, iplocate.GetAddressFromIPV2, "")
+	var we client.WorkflowRun
+	err = iplocate.CallWithTimeout(context.Background(), *timeout, func(ctx context.Context) error {
+		we, err = c.ExecuteWorkflow(ctx, workflowOptions, iplocate.GetAddressFromIPV2, "", lookupOptions)
+		return err
+	})
 	if err != nil {
 		log.Fatalln("Unable to execute workflow", err)
 	}
@@ -51,5 +85,46 @@ func main() {
 	log.Println("  WorkflowID:", we.GetID())
 	log.Println("  RunID:", we.GetRunID())
 	log.Println("  View in UI: http://localhost:8233")
-	log.Println("\nWorkflow executing in background. Starter exiting...")
+
+	if !*wait && *out == "" {
+		log.Println("\nWorkflow executing in background. Starter exiting...")
+		return
+	}
+
+	// Decode into raw JSON rather than iplocate.Data so this works for any
+	// workflow's result, including GetAddressFromIP's plain string.
+	var result json.RawMessage
+	if *cancelOnTimeout {
+		err = iplocate.AwaitResultOrCancel(context.Background(), c, we, &result, *waitTimeout)
+	} else {
+		err = iplocate.AwaitResult(context.Background(), we, &result, *waitTimeout)
+	}
+	if err != nil {
+		log.Fatalln("Workflow failed", err)
+	}
+	if err := writeResult(*out, result); err != nil {
+		log.Fatalln("Unable to write result", err)
+	}
+}
+
+func writeResult(path string, result json.RawMessage) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, result, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+
+	switch path {
+	case "":
+		log.Println("Result:", buf.String())
+		return nil
+	case "-":
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	log.Println("Result written to", path)
+	return nil
 }
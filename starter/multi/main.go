@@ -0,0 +1,111 @@
+// Command multi starts one lookup per IP given on the command line and
+// prints each result as soon as its workflow completes:
+//
+//	go run ./starter/multi 8.8.8.8 1.1.1.1 41.67.0.1
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"temporal-ip-geolocation/iplocate"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type lookupResult struct {
+	ip       string
+	location string
+	err      error
+}
+
+func main() {
+	timeout := flag.Duration("timeout", iplocate.DefaultClientTimeout, "deadline for each call to the Temporal server")
+	waitTimeout := flag.Duration("wait-timeout", 5*time.Minute, "give up waiting for each result after this long; zero waits forever")
+	flag.Parse()
+	ips := flag.Args()
+	if len(ips) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: multi [-timeout d] [-wait-timeout d] ip...")
+		os.Exit(2)
+	}
+
+	dialAttempts, err := iplocate.DialAttemptsFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	c, err := iplocate.DialWithRetry(client.Options{
+		HostPort:  "127.0.0.1:7233",
+		Namespace: "default",
+		ConnectionOptions: client.ConnectionOptions{
+			TLS: nil,
+			DialOptions: []grpc.DialOption{
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+			},
+		},
+	}, dialAttempts)
+	if err != nil {
+		log.Fatalln("Unable to create client", err)
+	}
+	defer c.Close()
+
+	// Buffered so an invalid IP can report without a goroutine.
+	results := make(chan lookupResult, len(ips))
+	for _, ip := range ips {
+		opts := iplocate.LookupOptions{IP: ip}
+		if !iplocate.DemoMode {
+			opts.DemoSleep = iplocate.NoDemoSleep
+		}
+		if err := opts.Validate(); err != nil {
+			results <- lookupResult{ip: ip, err: err}
+			continue
+		}
+		go func() {
+			location, err := lookup(c, *timeout, *waitTimeout, ip, opts)
+			results <- lookupResult{ip: ip, location: location, err: err}
+		}()
+	}
+
+	// Print in completion order; a failed IP doesn't stop the others.
+	var failed int
+	for range ips {
+		r := <-results
+		if r.err != nil {
+			failed++
+			fmt.Printf("%s: error: %v\n", r.ip, r.err)
+			continue
+		}
+		fmt.Printf("%s: %s\n", r.ip, r.location)
+	}
+	fmt.Printf("%d succeeded, %d failed\n", len(ips)-failed, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func lookup(c client.Client, timeout, waitTimeout time.Duration, ip string, opts iplocate.LookupOptions) (string, error) {
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        iplocate.WorkflowID("ip-geolocation-workflow", nil),
+		TaskQueue: iplocate.TaskQueueLookup,
+	}
+
+	var we client.WorkflowRun
+	err := iplocate.CallWithTimeout(context.Background(), timeout, func(ctx context.Context) error {
+		var err error
+		we, err = c.ExecuteWorkflow(ctx, workflowOptions, iplocate.GetAddressFromIP, "", opts)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("start workflow: %w", err)
+	}
+
+	var location string
+	if err := iplocate.AwaitResult(context.Background(), we, &location, waitTimeout); err != nil {
+		return "", err
+	}
+	return location, nil
+}
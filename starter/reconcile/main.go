@@ -0,0 +1,81 @@
+// Command reconcile creates, or updates the interval of, the schedule that
+// runs CacheReconcileWorkflow:
+//
+//	go run ./starter/reconcile -every 6h
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"temporal-ip-geolocation/iplocate"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const scheduleID = "cache-reconcile"
+
+func main() {
+	every := flag.Duration("every", 6*time.Hour, "how often to reconcile the cache")
+	timeout := flag.Duration("timeout", iplocate.DefaultClientTimeout, "deadline for each call to the Temporal server")
+	flag.Parse()
+	if *every <= 0 {
+		log.Fatalln("-every must be positive")
+	}
+
+	dialAttempts, err := iplocate.DialAttemptsFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	c, err := iplocate.DialWithRetry(client.Options{
+		HostPort:  "127.0.0.1:7233",
+		Namespace: "default",
+		ConnectionOptions: client.ConnectionOptions{
+			TLS: nil,
+			DialOptions: []grpc.DialOption{
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+			},
+		},
+	}, dialAttempts)
+	if err != nil {
+		log.Fatalln("Unable to create client", err)
+	}
+	defer c.Close()
+
+	spec := client.ScheduleSpec{Intervals: []client.ScheduleIntervalSpec{{Every: *every}}}
+	err = iplocate.CallWithTimeout(context.Background(), *timeout, func(ctx context.Context) error {
+		_, err := c.ScheduleClient().Create(ctx, client.ScheduleOptions{
+			ID:   scheduleID,
+			Spec: spec,
+			// A run still going when the next one is due has the cache covered.
+			Overlap: enumspb.SCHEDULE_OVERLAP_POLICY_SKIP,
+			Action: &client.ScheduleWorkflowAction{
+				ID:        scheduleID,
+				Workflow:  iplocate.CacheReconcileWorkflow,
+				Args:      []any{(*iplocate.ReconcileProgress)(nil)},
+				TaskQueue: iplocate.TaskQueueLookup,
+			},
+		})
+		return err
+	})
+	if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		err = iplocate.CallWithTimeout(context.Background(), *timeout, func(ctx context.Context) error {
+			return c.ScheduleClient().GetHandle(ctx, scheduleID).Update(ctx, client.ScheduleUpdateOptions{
+				DoUpdate: func(in client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+					in.Description.Schedule.Spec = &spec
+					return &client.ScheduleUpdate{Schedule: &in.Description.Schedule}, nil
+				},
+			})
+		})
+	}
+	if err != nil {
+		log.Fatalln("Unable to schedule cache reconciliation", err)
+	}
+	log.Printf("Schedule %s runs CacheReconcileWorkflow every %s", scheduleID, *every)
+}
@@ -0,0 +1,97 @@
+package iplocate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeSeriesSink stores each successful check for dashboards.
+type TimeSeriesSink interface {
+	Write(ip string, details LocationDetails, t time.Time) error
+}
+
+// NopSink drops every point. It is what WriteTimeSeries uses when
+// IPActivities.TimeSeries is nil.
+type NopSink struct{}
+
+func (NopSink) Write(ip string, details LocationDetails, t time.Time) error {
+	return nil
+}
+
+// InfluxSink writes points in InfluxDB line protocol to a write endpoint,
+// e.g. http://localhost:8086/api/v2/write?org=o&bucket=b&precision=ns.
+type InfluxSink struct {
+	URL   string
+	Token string
+	// Measurement defaults to "ip_location".
+	Measurement string
+	Client      *http.Client
+}
+
+func (s *InfluxSink) Write(ip string, details LocationDetails, t time.Time) error {
+	measurement := s.Measurement
+	if measurement == "" {
+		measurement = "ip_location"
+	}
+	line := InfluxLine(measurement, ip, details, t)
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, strings.NewReader(line))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Token "+s.Token)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("influx write: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+var (
+	influxTagEscaper    = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+	influxStringEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+// InfluxLine formats one check as a line-protocol point tagged with ip and,
+// when known, the country code.
+func InfluxLine(measurement, ip string, details LocationDetails, t time.Time) string {
+	var b bytes.Buffer
+	b.WriteString(influxTagEscaper.Replace(measurement))
+	b.WriteString(",ip=" + influxTagEscaper.Replace(ip))
+	if details.CountryCode != "" {
+		b.WriteString(",country_code=" + influxTagEscaper.Replace(details.CountryCode))
+	}
+	fmt.Fprintf(&b, ` city="%s",region="%s",country="%s",lat=%s,lon=%s %d`+"\n",
+		influxStringEscaper.Replace(details.City),
+		influxStringEscaper.Replace(details.Region),
+		influxStringEscaper.Replace(details.Country),
+		strconv.FormatFloat(details.Lat, 'f', -1, 64),
+		strconv.FormatFloat(details.Lon, 'f', -1, 64),
+		t.UnixNano())
+	return b.String()
+}
+
+// WriteTimeSeries passes one successful check to IPActivities.TimeSeries.
+func (i *IPActivities) WriteTimeSeries(ctx context.Context, ip string, details LocationDetails, at time.Time) error {
+	sink := i.TimeSeries
+	if sink == nil {
+		sink = NopSink{}
+	}
+	return sink.Write(ip, details, at)
+}
@@ -0,0 +1,84 @@
+package iplocate
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/testsuite"
+)
+
+type capturingSink struct {
+	points []LocationDetails
+}
+
+func (s *capturingSink) Write(ip string, details LocationDetails, t time.Time) error {
+	s.points = append(s.points, details)
+	return nil
+}
+
+func TestHourlyReportWorkflow_WritesTimeSeries(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	sink := &capturingSink{}
+	env.RegisterActivity(&IPActivities{TimeSeries: sink})
+	env.SetStartTime(time.Date(2026, 10, 15, 9, 50, 0, 0, time.UTC))
+
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "8.8.8.8").
+		Return(LocationDetails{CountryCode: "SD"}, nil).Once()
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "8.8.8.8").
+		Return(LocationDetails{}, errors.New("provider down")).Times(3)
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "8.8.8.8").
+		Return(LocationDetails{CountryCode: "US"}, nil)
+	env.OnActivity("EmitHourlySummary", mock.Anything, mock.Anything).Return(nil)
+
+	// Checks at 9:50, 9:55 (failed) and 10:00.
+	env.RegisterDelayedCallback(env.CancelWorkflow, 12*time.Minute)
+	env.ExecuteWorkflow(HourlyReportWorkflow, "8.8.8.8", (*HourlySummary)(nil))
+
+	if len(sink.points) != 2 || sink.points[0].CountryCode != "SD" || sink.points[1].CountryCode != "US" {
+		t.Errorf("points = %+v, want the two successful checks", sink.points)
+	}
+}
+
+func TestInfluxSink(t *testing.T) {
+	var body, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body, auth = string(b), r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink := &InfluxSink{URL: srv.URL + "/api/v2/write?bucket=geo", Token: "secret"}
+	details := LocationDetails{City: "Port Sudan", Country: `Sudan "SD"`, CountryCode: "SD", Lat: 19.6158, Lon: 37.2164}
+	if err := sink.Write("41.67.0.1", details, time.Unix(0, 1760500000000000000)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `ip_location,ip=41.67.0.1,country_code=SD city="Port Sudan",region="",country="Sudan \"SD\"",lat=19.6158,lon=37.2164 1760500000000000000` + "\n"
+	if body != want {
+		t.Errorf("line = %q, want %q", body, want)
+	}
+	if auth != "Token secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+
+	failing := &InfluxSink{URL: srv.URL + "/missing"}
+	srv.Config.Handler = http.NotFoundHandler()
+	if err := failing.Write("41.67.0.1", details, time.Now()); err == nil {
+		t.Error("expected an error for a 404 response")
+	}
+}
+
+func TestInfluxLine_Escaping(t *testing.T) {
+	got := InfluxLine("geo data", "::1", LocationDetails{City: `a\b`}, time.Unix(0, 5))
+	want := `geo\ data,ip=::1 city="a\\b",region="",country="",lat=0,lon=0 5` + "\n"
+	if got != want {
+		t.Errorf("InfluxLine = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,38 @@
+package iplocate
+
+import "math"
+
+type offsetRegion struct {
+	minLat, maxLat float64
+	minLon, maxLon float64
+	offset         int
+}
+
+// offsetRegions covers large areas whose civil time is far from the
+// longitude-based nautical zone. The first matching box wins, so narrower
+// boxes come before the ones they overlap.
+var offsetRegions = []offsetRegion{
+	{49.5, 61, -11, 2, 0},        // Great Britain and Ireland
+	{36.5, 42.5, -10, -6.2, 0},   // Portugal
+	{36, 71, -6.2, 16, 1},        // western and central Europe, incl. Spain and France
+	{33, 43, 124.5, 131, 9},      // Korea
+	{35, 50, 73, 97, 8},          // Xinjiang, on Beijing time
+	{22, 54, 97, 135, 8},         // rest of China and Mongolia
+	{6, 30, 68, 89, 5},           // India (UTC+5:30, rounded down)
+	{-55, -21.5, -74, -53.5, -3}, // Argentina
+}
+
+// OffsetFromCoords estimates the standard-time UTC offset, in whole hours,
+// for a coordinate using only the bundled table above and the nautical
+// zone (longitude / 15) elsewhere. It ignores daylight saving and half-hour
+// zones and is coarse near borders, so a cross-check against a provider's
+// timezone should tolerate an hour of difference. It has no I/O and is
+// safe to call from workflow code.
+func OffsetFromCoords(lat, lon float64) int {
+	for _, r := range offsetRegions {
+		if lat >= r.minLat && lat <= r.maxLat && lon >= r.minLon && lon <= r.maxLon {
+			return r.offset
+		}
+	}
+	return int(math.Round(lon / 15))
+}
@@ -0,0 +1,32 @@
+package iplocate
+
+import "testing"
+
+func TestOffsetFromCoords(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     int
+	}{
+		{"London", 51.51, -0.13, 0},
+		{"Lisbon", 38.72, -9.14, 0},
+		{"Paris", 48.86, 2.35, 1},
+		{"Madrid", 40.42, -3.70, 1},
+		{"Khartoum", 15.50, 32.56, 2},
+		{"Moscow", 55.76, 37.62, 3},
+		{"Delhi", 28.61, 77.21, 5},
+		{"Urumqi", 43.83, 87.62, 8},
+		{"Beijing", 39.90, 116.41, 8},
+		{"Seoul", 37.57, 126.98, 9},
+		{"Tokyo", 35.68, 139.69, 9},
+		{"Sydney", -33.87, 151.21, 10},
+		{"Buenos Aires", -34.60, -58.38, -3},
+		{"New York", 40.71, -74.01, -5},
+		{"Los Angeles", 34.05, -118.24, -8},
+	}
+	for _, tt := range tests {
+		if got := OffsetFromCoords(tt.lat, tt.lon); got != tt.want {
+			t.Errorf("%s: OffsetFromCoords(%v, %v) = %d, want %d", tt.name, tt.lat, tt.lon, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package iplocate
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ErrLocationTimeout is the application error type WaitForLocationWorkflow
+// fails with when the IP never reaches the target country.
+const ErrLocationTimeout = "LocationTimeout"
+
+// WaitForLocationWorkflow looks ip up with PrimaryProvider every interval
+// (less Backoff's jitter) until it resolves to targetCountryCode, and
+// returns that lookup. With a positive timeout it fails with
+// ErrLocationTimeout once timeout has passed; zero polls indefinitely.
+func WaitForLocationWorkflow(ctx workflow.Context, ip string, targetCountryCode string, interval time.Duration, timeout time.Duration) (LocationDetails, error) {
+	if net.ParseIP(ip) == nil {
+		return LocationDetails{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("invalid ip %q", ip), ErrInvalidInput, nil)
+	}
+	if targetCountryCode == "" {
+		return LocationDetails{}, temporal.NewNonRetryableApplicationError(
+			"target country code is required", ErrInvalidInput, nil)
+	}
+	if interval <= 0 {
+		return LocationDetails{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("interval must be positive, got %s", interval), ErrInvalidInput, nil)
+	}
+
+	ao := ActivityConfig{}.Options()
+	ctx = workflow.WithActivityOptions(ctx, ao)
+	logger := workflow.GetLogger(ctx)
+
+	var deadline workflow.Future
+	if timeout > 0 {
+		deadline = workflow.NewTimer(ctx, timeout)
+	}
+
+	// Runs started before polls were jittered waited exactly interval.
+	jitter := workflow.GetVersion(ctx, "poll-jitter", workflow.DefaultVersion, 1) == 1
+
+	for attempt := 1; ; attempt++ {
+		var details LocationDetails
+		err := executeProviderLookup(ctx, PrimaryProvider, ip).Get(ctx, &details)
+		if err != nil {
+			return LocationDetails{}, fmt.Errorf("failed to look up %s: %w", ip, err)
+		}
+		if strings.EqualFold(details.CountryCode, targetCountryCode) {
+			return details, nil
+		}
+		logger.Info("Target location not reached", "ip", ip, "country", details.CountryCode, "target", targetCountryCode)
+
+		wait := interval
+		if jitter {
+			wait = Backoff(ctx, attempt, interval, interval)
+		}
+		timedOut := false
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		selector := workflow.NewSelector(ctx)
+		selector.AddFuture(workflow.NewTimer(timerCtx, wait), func(workflow.Future) {})
+		if deadline != nil {
+			selector.AddFuture(deadline, func(workflow.Future) { timedOut = true })
+		}
+		selector.Select(ctx)
+		cancelTimer()
+
+		if timedOut {
+			return LocationDetails{}, temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("%s did not resolve to %s within %s", ip, targetCountryCode, timeout),
+				ErrLocationTimeout, nil)
+		}
+	}
+}
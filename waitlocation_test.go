@@ -0,0 +1,57 @@
+package iplocate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestWaitForLocationWorkflow(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "8.8.8.8").
+		Return(LocationDetails{IP: "8.8.8.8", CountryCode: "US"}, nil).Twice()
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "8.8.8.8").
+		Return(LocationDetails{IP: "8.8.8.8", City: "Berlin", CountryCode: "DE"}, nil).Once()
+
+	start := env.Now()
+	env.ExecuteWorkflow(WaitForLocationWorkflow, "8.8.8.8", "de", 10*time.Minute, time.Hour)
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	var got LocationDetails
+	if err := env.GetWorkflowResult(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.City != "Berlin" {
+		t.Errorf("result = %+v, want the Berlin lookup", got)
+	}
+	// Each poll waits between 80% and 100% of the interval.
+	if elapsed := env.Now().Sub(start); elapsed < 16*time.Minute || elapsed > 20*time.Minute {
+		t.Errorf("elapsed = %s, want two jittered intervals", elapsed)
+	}
+	env.AssertExpectations(t)
+}
+
+func TestWaitForLocationWorkflow_Timeout(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "8.8.8.8").
+		Return(LocationDetails{IP: "8.8.8.8", CountryCode: "US"}, nil)
+
+	env.ExecuteWorkflow(WaitForLocationWorkflow, "8.8.8.8", "DE", 10*time.Minute, 25*time.Minute)
+
+	var appErr *temporal.ApplicationError
+	if err := env.GetWorkflowError(); !errors.As(err, &appErr) || appErr.Type() != ErrLocationTimeout {
+		t.Fatalf("err = %v, want %s", err, ErrLocationTimeout)
+	}
+}
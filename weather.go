@@ -0,0 +1,139 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+type WeatherInfo struct {
+	TemperatureC float64
+	// Condition describes WeatherCode, e.g. "Partly cloudy".
+	Condition string
+	// WeatherCode is the WMO weather interpretation code.
+	WeatherCode int
+}
+
+// LocationWeather is the result of GetAddressWithWeatherWorkflow. Weather
+// is nil when the weather lookup failed, and WeatherError says why.
+type LocationWeather struct {
+	Location     LocationDetails
+	Weather      *WeatherInfo
+	WeatherError string
+}
+
+// GetWeather returns the current weather at lat, lon from open-meteo, which
+// needs no API key.
+func (i *IPActivities) GetWeather(ctx context.Context, lat, lon float64) (WeatherInfo, error) {
+	url := fmt.Sprintf("https://api.open-meteo.com/v1/forecast?latitude=%g&longitude=%g&current=temperature_2m,weather_code", lat, lon)
+
+	resp, err := i.get(ctx, url)
+	if err != nil {
+		return WeatherInfo{}, lookupError(CategoryNetwork, "HTTP GET", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return WeatherInfo{}, lookupError(CategoryNetwork, "read body", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return WeatherInfo{}, lookupError(CategoryProviderFail, "open-meteo", fmt.Errorf("unexpected status %s", resp.Status))
+	}
+
+	var data struct {
+		Current struct {
+			Temperature float64 `json:"temperature_2m"`
+			WeatherCode int     `json:"weather_code"`
+		} `json:"current"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return WeatherInfo{}, lookupError(CategoryParse, "JSON unmarshal", err)
+	}
+
+	return WeatherInfo{
+		TemperatureC: data.Current.Temperature,
+		Condition:    weatherCondition(data.Current.WeatherCode),
+		WeatherCode:  data.Current.WeatherCode,
+	}, nil
+}
+
+// weatherCondition groups the WMO codes open-meteo reports.
+func weatherCondition(code int) string {
+	switch {
+	case code == 0:
+		return "Clear sky"
+	case code <= 2:
+		return "Partly cloudy"
+	case code == 3:
+		return "Overcast"
+	case code == 45 || code == 48:
+		return "Fog"
+	case code >= 51 && code <= 57:
+		return "Drizzle"
+	case code >= 61 && code <= 67, code >= 80 && code <= 82:
+		return "Rain"
+	case code >= 71 && code <= 77, code == 85 || code == 86:
+		return "Snow"
+	case code >= 95:
+		return "Thunderstorm"
+	}
+	return "Unknown"
+}
+
+// GetAddressWithWeatherWorkflow looks ip up with PrimaryProvider, then
+// fetches the current weather at its coordinates. A failed weather lookup,
+// or a location without coordinates, is reported in the result rather than
+// failing the workflow.
+//
+// minFields lists LocationDetails fields, or CoordinatesField, that must
+// be non-empty. When the primary result leaves one empty SecondaryProvider
+// is tried, and if it does too the workflow fails with ErrLowConfidence.
+func GetAddressWithWeatherWorkflow(ctx workflow.Context, ip string, minFields []string) (LocationWeather, error) {
+	if net.ParseIP(ip) == nil {
+		return LocationWeather{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("invalid ip %q", ip), ErrInvalidInput, nil)
+	}
+	if _, err := missingFields(LocationDetails{}, minFields); err != nil {
+		return LocationWeather{}, temporal.NewNonRetryableApplicationError(err.Error(), ErrInvalidInput, nil)
+	}
+
+	ao := ActivityConfig{}.Options()
+	var ipActivities *IPActivities
+	ctx = workflow.WithActivityOptions(ctx, ao)
+
+	var result LocationWeather
+	var err error
+	result.Location, err = locateWithFallback(ctx, ip, minFields)
+	if err != nil {
+		return LocationWeather{}, err
+	}
+
+	// 0,0 means the provider gave no coordinates, as with CoordinatesField;
+	// the weather there would be the Gulf of Guinea's. Runs started before
+	// this check fetched it anyway.
+	if result.Location.Lat == 0 && result.Location.Lon == 0 &&
+		workflow.GetVersion(ctx, "weather-needs-coordinates", workflow.DefaultVersion, 1) == 1 {
+		result.WeatherError = "location has no coordinates"
+		return result, nil
+	}
+
+	// The weather is extra; don't hold the location up for the full
+	// DefaultRetryPolicy schedule when the weather API is down.
+	wctx := workflow.WithActivityOptions(ctx, ActivityConfig{MaximumAttempts: 3}.Options())
+
+	var weather WeatherInfo
+	err = workflow.ExecuteActivity(wctx, ipActivities.GetWeather, result.Location.Lat, result.Location.Lon).Get(wctx, &weather)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Weather unavailable", "ip", ip, "error", err)
+		result.WeatherError = err.Error()
+		return result, nil
+	}
+	result.Weather = &weather
+	return result, nil
+}
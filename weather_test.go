@@ -0,0 +1,133 @@
+package iplocate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestIPActivities_GetWeather(t *testing.T) {
+	a := &IPActivities{HTTPClient: &stubGetter{bodies: map[string]string{
+		"https://api.open-meteo.com/v1/forecast?latitude=15.5518&longitude=32.5324&current=temperature_2m,weather_code": `{
+			"latitude": 15.55, "longitude": 32.53,
+			"current": {"time": "2026-10-15T12:00", "temperature_2m": 38.4, "weather_code": 2}
+		}`,
+	}}}
+
+	got, err := a.GetWeather(context.Background(), 15.5518, 32.5324)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (WeatherInfo{TemperatureC: 38.4, Condition: "Partly cloudy", WeatherCode: 2}); got != want {
+		t.Errorf("GetWeather = %+v, want %+v", got, want)
+	}
+
+	down := &IPActivities{HTTPClient: &statusGetter{status: http.StatusServiceUnavailable}}
+	if _, err := down.GetWeather(context.Background(), 0, 0); ErrorCategoryOf(err) != CategoryProviderFail {
+		t.Errorf("err = %v, want a provider failure", err)
+	}
+}
+
+func TestGetAddressWithWeatherWorkflow_WeatherDown(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	location := LocationDetails{IP: "41.67.0.1", City: "Khartoum", Lat: 15.5518, Lon: 32.5324}
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "41.67.0.1").Return(location, nil)
+	env.OnActivity("GetWeather", mock.Anything, 15.5518, 32.5324).Return(WeatherInfo{}, errors.New("open-meteo down")).Times(3)
+
+	env.ExecuteWorkflow(GetAddressWithWeatherWorkflow, "41.67.0.1", []string(nil))
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	var got LocationWeather
+	if err := env.GetWorkflowResult(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Location != location || got.Weather != nil || got.WeatherError == "" {
+		t.Errorf("result = %+v, want the location and a weather error", got)
+	}
+	env.AssertExpectations(t)
+}
+
+func TestGetAddressWithWeatherWorkflow_NoCoordinates(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	location := LocationDetails{IP: "41.67.0.1", City: "Khartoum"}
+	env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "41.67.0.1").Return(location, nil)
+
+	env.ExecuteWorkflow(GetAddressWithWeatherWorkflow, "41.67.0.1", []string(nil))
+	var got LocationWeather
+	if err := env.GetWorkflowResult(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Location != location || got.Weather != nil || got.WeatherError == "" {
+		t.Errorf("result = %+v, want the location and a weather error", got)
+	}
+	env.AssertNotCalled(t, "GetWeather", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetAddressWithWeatherWorkflow_MinFields(t *testing.T) {
+	countryOnly := LocationDetails{IP: "41.67.0.1", Country: "Sudan", CountryCode: "SD"}
+	full := LocationDetails{IP: "41.67.0.1", City: "Khartoum", Country: "Sudan", CountryCode: "SD", Lat: 15.5518, Lon: 32.5324}
+
+	for name, tc := range map[string]struct {
+		secondary LocationDetails
+		wantErr   string
+	}{
+		"fallback fills the gap":  {secondary: full},
+		"fallback is partial too": {secondary: countryOnly, wantErr: ErrLowConfidence},
+	} {
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestWorkflowEnvironment()
+		env.RegisterActivity(&IPActivities{})
+
+		env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "41.67.0.1").Return(countryOnly, nil)
+		env.OnActivity("ProviderLookup", mock.Anything, SecondaryProvider, "41.67.0.1").Return(tc.secondary, nil)
+		env.OnActivity("GetWeather", mock.Anything, mock.Anything, mock.Anything).Return(WeatherInfo{TemperatureC: 38}, nil)
+
+		env.ExecuteWorkflow(GetAddressWithWeatherWorkflow, "41.67.0.1", []string{"city", "Country"})
+
+		err := env.GetWorkflowError()
+		if tc.wantErr != "" {
+			var appErr *temporal.ApplicationError
+			if !errors.As(err, &appErr) || appErr.Type() != tc.wantErr {
+				t.Errorf("%s: err = %v, want %s", name, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: workflow failed: %v", name, err)
+		}
+		var got LocationWeather
+		if err := env.GetWorkflowResult(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Location != full {
+			t.Errorf("%s: location = %+v, want the fallback's %+v", name, got.Location, full)
+		}
+	}
+}
+
+func TestMissingFields(t *testing.T) {
+	d := LocationDetails{Country: "Sudan", Lat: 15.5}
+	missing, err := missingFields(d, []string{"Country", "City", "coordinates", "Timezone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"City", "Timezone"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing = %v, want %v", missing, want)
+	}
+	if _, err := missingFields(d, []string{"Postcode"}); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
@@ -0,0 +1,39 @@
+package iplocate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrWebhookRejected is the application error type for callbacks answered
+// with a 4xx other than 429. They are not retried.
+const ErrWebhookRejected = "WebhookRejected"
+
+// NotifyWebhook POSTs result as JSON to url. Any 2xx counts as delivered.
+func (i *IPActivities) NotifyWebhook(ctx context.Context, url string, result Data) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshal result: %w", err)
+	}
+
+	resp, err := i.send(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return fmt.Errorf("POST %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("POST %s: %s", url, resp.Status), ErrWebhookRejected, nil)
+	}
+	return fmt.Errorf("POST %s: %s", url, resp.Status)
+}
@@ -0,0 +1,73 @@
+package iplocate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestGetAddressFromIPV2_NotifiesCallback(t *testing.T) {
+	received := make(chan Data, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d Data
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&d) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		received <- d
+	}))
+	defer srv.Close()
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{HTTPClient: srv.Client()})
+
+	env.OnActivity("GetIP", mock.Anything).Return("8.8.8.8", nil)
+	env.OnActivity("RecordLookup", mock.Anything, "8.8.8.8", mock.Anything).Return("1-8.8.8.8", nil)
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("City: Ashburn", nil)
+	env.OnActivity("GetTimeZone", mock.Anything, "8.8.8.8").Return("America/New_York", nil)
+
+	env.ExecuteWorkflow(GetAddressFromIPV2, "", LookupOptions{DemoSleep: NoDemoSleep, CallbackURL: srv.URL + "/done"})
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+
+	want := Data{Result: "8.8.8.8", Location: "City: Ashburn", Zone: "America/New_York"}
+	select {
+	case got := <-received:
+		if got != want {
+			t.Errorf("callback body = %+v, want %+v", got, want)
+		}
+	default:
+		t.Fatal("callback was not called")
+	}
+}
+
+func TestGetAddressFromIPV2_CallbackFailureKeepsResult(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer srv.Close()
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{HTTPClient: srv.Client()})
+
+	env.OnActivity("GetIP", mock.Anything).Return("8.8.8.8", nil)
+	env.OnActivity("RecordLookup", mock.Anything, "8.8.8.8", mock.Anything).Return("1-8.8.8.8", nil)
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("City: Ashburn", nil)
+	env.OnActivity("GetTimeZone", mock.Anything, "8.8.8.8").Return("America/New_York", nil)
+
+	env.ExecuteWorkflow(GetAddressFromIPV2, "", LookupOptions{DemoSleep: NoDemoSleep, CallbackURL: srv.URL})
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed on a callback error: %v", err)
+	}
+	var got Data
+	if err := env.GetWorkflowResult(&got); err != nil || got.Result != "8.8.8.8" {
+		t.Errorf("result = %+v, %v", got, err)
+	}
+}
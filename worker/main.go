@@ -1,41 +1,186 @@
 package main
 
 import (
+	"expvar"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"temporal-ip-geolocation/iplocate"
+	"time"
 
 	"go.temporal.io/sdk/client"
+	sdklog "go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/worker"
 )
 
 func main() {
+	logger, err := iplocate.LoggerFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// LoggingGetter logs through slog.Default(); the standard log package
+	// keeps printing directly so fatal errors show at any level.
+	iplocate.SetDefaultLogger(logger)
+
+	dialAttempts, err := iplocate.DialAttemptsFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
 	log.Println("Attempting to connect to Temporal server at: 127.0.0.1:7233")
-	c, err := client.Dial(client.Options{
+	c, err := iplocate.DialWithRetry(client.Options{
 		HostPort:  "127.0.0.1:7233",
 		Namespace: "default",
+		Logger:    sdklog.NewStructuredLogger(logger),
 		// ConnectionOptions: client.ConnectionOptions{
 		// 	TLS: nil, // Use insecure connection for local dev
 		// 	DialOptions: []grpc.DialOption{
 		// 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		// 	},
 		// },
-	})
+	}, dialAttempts)
 	if err != nil {
 		log.Fatalln("error in dialing: ", err)
 	}
 	defer c.Close()
 	log.Println("Successfully connected to Temporal server")
 
-	w := worker.New(c, iplocate.TaskQueueName, worker.Options{})
+	taskQueue := iplocate.TaskQueueLookup
+	if v := os.Getenv("WORKER_SHARD"); v != "" {
+		shard, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalln("invalid WORKER_SHARD:", err)
+		}
+		taskQueue = iplocate.ShardTaskQueue(shard)
+	}
+	workerOptions, err := iplocate.WorkerOptionsFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if v := os.Getenv("ACTIVITY_ROUTES"); v != "" {
+		routes, err := iplocate.ParseActivityRoutes(v)
+		if err != nil {
+			log.Fatalln("invalid ACTIVITY_ROUTES:", err)
+		}
+		workerOptions.Interceptors = append(workerOptions.Interceptors, &iplocate.RoutingInterceptor{Routes: routes})
+	}
+	w := worker.New(c, taskQueue, workerOptions)
+
+	if v := os.Getenv(iplocate.MaxResponseBytesEnv); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			log.Fatalf("invalid %s: %q", iplocate.MaxResponseBytesEnv, v)
+		}
+		iplocate.MaxResponseBytes = n
+	}
+	// Honours HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+	var httpClient iplocate.HTTPGetter = iplocate.NewHTTPClient(iplocate.HTTPConfig{})
+	if os.Getenv("LOG_HTTP") != "" {
+		httpClient = &iplocate.LoggingGetter{Getter: httpClient, LogBodySize: true}
+	}
+	activities := &iplocate.IPActivities{HTTPClient: httpClient}
+	// Provider lookups share the activities' MaxConcurrent slots.
+	providerGetter := iplocate.SharedGetter(activities)
+	activities.Providers = map[string]iplocate.GeoProvider{
+		iplocate.PrimaryProvider:   iplocate.NewIPAPIProvider(providerGetter),
+		iplocate.SecondaryProvider: iplocate.NewIPWhoisProvider(providerGetter),
+	}
+	if token := os.Getenv("IPINFO_TOKEN"); token != "" {
+		activities.Providers[iplocate.SecondaryProvider] = iplocate.NewIPInfoProvider(token, providerGetter)
+	}
+	if path := os.Getenv("LOCATION_OVERRIDES"); path != "" {
+		p, err := iplocate.NewOverrideProvider(path, activities.Providers[iplocate.PrimaryProvider])
+		if err != nil {
+			log.Fatalln("unable to load location overrides", err)
+		}
+		activities.Providers[iplocate.PrimaryProvider] = p
+	}
+	var maxmindWorker worker.Worker
+	if path := os.Getenv("MAXMIND_DB"); path != "" {
+		p, err := iplocate.NewMaxMindProvider(path)
+		if err != nil {
+			log.Fatalln("unable to open MaxMind database", err)
+		}
+		defer p.Close()
+		// Answers the primary lookups routed to this queue from the
+		// database, e.g. with ACTIVITY_ROUTES=ProviderLookup/primary=ip-finder-maxmind.
+		queue := os.Getenv("MAXMIND_TASK_QUEUE")
+		if queue == "" {
+			queue = iplocate.TaskQueueMaxMind
+		}
+		maxmindWorker = worker.New(c, queue, worker.Options{DisableWorkflowWorker: true})
+		maxmindWorker.RegisterActivity(&iplocate.IPActivities{
+			HTTPClient: httpClient,
+			Providers: map[string]iplocate.GeoProvider{
+				iplocate.PrimaryProvider:   p,
+				iplocate.SecondaryProvider: activities.Providers[iplocate.SecondaryProvider],
+			},
+		})
+	}
+	if path := os.Getenv("ASN_DATASET"); path != "" {
+		names, err := iplocate.LoadASNames(path)
+		if err != nil {
+			log.Fatalln("unable to load ASN dataset", err)
+		}
+		activities.ASNames = names
+	}
+	if v := os.Getenv("MAX_CONCURRENT_LOOKUPS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalln("invalid MAX_CONCURRENT_LOOKUPS:", err)
+		}
+		activities.MaxConcurrent = n
+	}
+	if url := os.Getenv("INFLUX_WRITE_URL"); url != "" {
+		activities.TimeSeries = &iplocate.InfluxSink{URL: url, Token: os.Getenv("INFLUX_TOKEN")}
+	}
+	if path := os.Getenv("AUDIT_LOG"); path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			log.Fatalln("unable to open audit log", err)
+		}
+		defer f.Close()
+		activities.AuditLog = f
+	}
+	iplocate.PublishLatency("iplocate_http_latency", activities)
+
+	// ip-api rate limits per source IP, so log which one this worker uses.
+	if ip, err := iplocate.PublicIP(iplocate.NewHTTPClient(iplocate.HTTPConfig{Timeout: 5 * time.Second})); err != nil {
+		log.Println("WARN: could not resolve worker egress IP:", err)
+	} else {
+		log.Println("Worker egress IP:", ip)
+		expvar.NewString("iplocate_egress_ip").Set(ip)
+	}
 
-	activities := &iplocate.IPActivities{
-		HTTPClient: http.DefaultClient,
+	if addr := os.Getenv("DEBUG_ADDR"); addr != "" {
+		// expvar registers /debug/vars on the default mux
+		go func() {
+			log.Println("Serving /debug/vars on", addr)
+			if err := http.ListenAndServe(addr, nil); err != nil {
+				log.Println("debug server stopped:", err)
+			}
+		}()
 	}
-	w.RegisterWorkflow(iplocate.GetAddressFromIP)
-	w.RegisterWorkflow(iplocate.GetAddressFromIPV2)
+	iplocate.RegisterLookupWorkflows(w)
 	w.RegisterActivity(activities)
 
+	// Monitors get their own queue and task slots so a burst of lookups
+	// can't starve them. Their activities run on the same queue.
+	monitorWorker := worker.New(c, iplocate.TaskQueueMonitor, workerOptions)
+	iplocate.RegisterMonitorWorkflows(monitorWorker)
+	monitorWorker.RegisterActivity(activities)
+	if err := monitorWorker.Start(); err != nil {
+		log.Fatalln("unable to start monitor worker", err)
+	}
+	defer monitorWorker.Stop()
+
+	if maxmindWorker != nil {
+		if err := maxmindWorker.Start(); err != nil {
+			log.Fatalln("unable to start MaxMind worker", err)
+		}
+		defer maxmindWorker.Stop()
+	}
+
 	err = w.Run(worker.InterruptCh())
 	if err != nil {
 		log.Fatalln("unable to start temporal worker", err)
@@ -0,0 +1,48 @@
+package iplocate
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.temporal.io/sdk/worker"
+)
+
+// Environment variables read by WorkerOptionsFromEnv. Unset means the SDK
+// default of 1000 for both.
+const (
+	MaxConcurrentActivitiesEnv    = "MAX_CONCURRENT_ACTIVITIES"
+	MaxConcurrentWorkflowTasksEnv = "MAX_CONCURRENT_WORKFLOW_TASKS"
+)
+
+// WorkerOptionsFromEnv returns worker.Options with the concurrency limits
+// from MaxConcurrentActivitiesEnv and MaxConcurrentWorkflowTasksEnv.
+func WorkerOptionsFromEnv() (worker.Options, error) {
+	var opts worker.Options
+	activities, err := positiveEnv(MaxConcurrentActivitiesEnv, 1)
+	if err != nil {
+		return worker.Options{}, err
+	}
+	// The SDK rejects a single workflow task slot: the worker would then
+	// only ever poll its sticky queue.
+	tasks, err := positiveEnv(MaxConcurrentWorkflowTasksEnv, 2)
+	if err != nil {
+		return worker.Options{}, err
+	}
+	opts.MaxConcurrentActivityExecutionSize = activities
+	opts.MaxConcurrentWorkflowTaskExecutionSize = tasks
+	return opts, nil
+}
+
+// positiveEnv returns 0 when name is unset.
+func positiveEnv(name string, min int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min {
+		return 0, fmt.Errorf("%s must be an integer of at least %d, got %q", name, min, v)
+	}
+	return n, nil
+}
@@ -0,0 +1,32 @@
+package iplocate
+
+import "testing"
+
+func TestWorkerOptionsFromEnv(t *testing.T) {
+	t.Setenv(MaxConcurrentActivitiesEnv, "")
+	t.Setenv(MaxConcurrentWorkflowTasksEnv, "")
+	opts, err := WorkerOptionsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.MaxConcurrentActivityExecutionSize != 0 || opts.MaxConcurrentWorkflowTaskExecutionSize != 0 {
+		t.Errorf("unset env: options = %+v, want SDK defaults", opts)
+	}
+
+	t.Setenv(MaxConcurrentActivitiesEnv, "8")
+	t.Setenv(MaxConcurrentWorkflowTasksEnv, "4")
+	opts, err = WorkerOptionsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.MaxConcurrentActivityExecutionSize != 8 || opts.MaxConcurrentWorkflowTaskExecutionSize != 4 {
+		t.Errorf("options = %+v, want 8 activities and 4 workflow tasks", opts)
+	}
+
+	for _, v := range []string{"1", "0", "many"} {
+		t.Setenv(MaxConcurrentWorkflowTasksEnv, v)
+		if _, err := WorkerOptionsFromEnv(); err == nil {
+			t.Errorf("%s=%q: expected an error", MaxConcurrentWorkflowTasksEnv, v)
+		}
+	}
+}
@@ -2,96 +2,336 @@ package iplocate
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
-func GetAddressFromIP(ctx workflow.Context, name string) (string, error) {
-	ao := workflow.ActivityOptions{
-		StartToCloseTimeout: time.Minute,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    time.Second,
-			MaximumInterval:    time.Minute,
-			BackoffCoefficient: 2,
-		},
+// DefaultDemoSleep is how long the teaching workflows pause between steps so
+// there is time to edit the code while a run is in flight.
+const DefaultDemoSleep = 45 * time.Second
+
+// NoDemoSleep disables the pause entirely, e.g. in tests.
+const NoDemoSleep time.Duration = -1
+
+// DemoModeEnv turns the teaching pauses off when set to a false value such
+// as "false" or "0".
+const DemoModeEnv = "IPLOCATE_DEMO_MODE"
+
+// DemoMode is read by starters, which set LookupOptions.DemoSleep to
+// NoDemoSleep when it is false. Workflows never read it: the pause is part
+// of the workflow input so that replays sleep exactly as the original run
+// did. Changing how an in-flight workflow type sleeps still needs a new
+// GetVersion change ID.
+var DemoMode = demoModeFromEnv()
+
+func demoModeFromEnv() bool {
+	enabled, err := strconv.ParseBool(os.Getenv(DemoModeEnv))
+	return err != nil || enabled
+}
+
+type LookupOptions struct {
+	// IP to look up. When empty the worker's own public IP is used.
+	IP string
+	// DemoSleep defaults to DefaultDemoSleep when zero, or to 30 seconds
+	// in GetAddressFromIPV2.
+	DemoSleep time.Duration
+	// PublishSubject, when set, makes GetAddressFromIPV2 publish its result
+	// there through the worker's Publisher.
+	PublishSubject string
+	// CallbackURL, when set, makes GetAddressFromIPV2 POST its result there
+	// as JSON once the lookup is done.
+	CallbackURL string
+	// RequireTimezone makes GetAddressFromIPV2 fail when the timezone lookup
+	// fails. By default it returns the location with an empty Zone instead.
+	RequireTimezone bool
+	// MinFields lists LocationDetails fields, or CoordinatesField, that the
+	// location must have. When set, the location comes from PrimaryProvider,
+	// then SecondaryProvider if that leaves one empty, and the workflow
+	// fails with ErrLowConfidence if neither fills them all.
+	MinFields []string
+}
+
+// ErrInvalidInput is the application error type for workflow inputs that
+// fail validation. Such failures are never retried.
+const ErrInvalidInput = "InvalidInput"
+
+// Validate checks the options before a workflow acts on them. Starters
+// should call it before ExecuteWorkflow to fail fast client-side.
+func (o LookupOptions) Validate() error {
+	if o.IP != "" && net.ParseIP(o.IP) == nil {
+		return temporal.NewNonRetryableApplicationError(fmt.Sprintf("invalid ip %q", o.IP), ErrInvalidInput, nil)
+	}
+	if strings.ContainsAny(o.PublishSubject, " \t\r\n") {
+		return temporal.NewNonRetryableApplicationError(fmt.Sprintf("publish subject %q contains whitespace", o.PublishSubject), ErrInvalidInput, nil)
+	}
+	if o.CallbackURL != "" {
+		u, err := url.Parse(o.CallbackURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return temporal.NewNonRetryableApplicationError(fmt.Sprintf("callback url %q must be an absolute http(s) url", o.CallbackURL), ErrInvalidInput, nil)
+		}
 	}
+	if _, err := missingFields(LocationDetails{}, o.MinFields); err != nil {
+		return temporal.NewNonRetryableApplicationError(err.Error(), ErrInvalidInput, nil)
+	}
+	return nil
+}
+
+func (o LookupOptions) demoSleep() time.Duration {
+	switch {
+	case o.DemoSleep == 0:
+		return DefaultDemoSleep
+	case o.DemoSleep < 0:
+		return 0
+	}
+	return o.DemoSleep
+}
+
+// minFieldsFor returns opts.MinFields, or nil in runs started before the
+// lookup workflows checked it. Runs without MinFields record no version.
+func minFieldsFor(ctx workflow.Context, describe *describer, opts LookupOptions) []string {
+	if len(opts.MinFields) == 0 || describe.getVersion(ctx, "min-fields", workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return nil
+	}
+	return opts.MinFields
+}
+
+func GetAddressFromIP(ctx workflow.Context, name string, opts LookupOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	describe, err := registerDescribe(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ao := ActivityConfig{HeartbeatTimeout: LookupHeartbeatTimeout}.Options()
 	var ipActivities *IPActivities
 	ctx = workflow.WithActivityOptions(ctx, ao)
+	if err := registerRetryPolicy(ctx, ao.RetryPolicy); err != nil {
+		return "", err
+	}
 
 	workflow.GetLogger(ctx).Info("Version 1: Starting workflow - will fetch IP, wait, then get location")
 
-	var ip string
-	err := workflow.ExecuteActivity(ctx, ipActivities.GetIP).Get(ctx, &ip)
-	if err != nil {
-		return "", fmt.Errorf("failed to get ip: %s", err)
+	// Runs started before LookupOptions existed always fetched the IP and
+	// slept for 45 seconds; keep replaying them that way.
+	v := describe.getVersion(ctx, "lookup-options", workflow.DefaultVersion, 1)
+	if v == workflow.DefaultVersion {
+		opts = LookupOptions{}
 	}
-	workflow.GetLogger(ctx).Info("IP fetched", "ip", ip)
-	// Sleep for 45 seconds to give us time to modify code while workflow is running
-	workflow.GetLogger(ctx).Info("Sleeping for 45 seconds... (this is when you'll modify the code)")
-	workflow.Sleep(ctx, 45*time.Second)
-	workflow.GetLogger(ctx).Info("Awake! Now fetching location...")
 
-	var location string
-	err = workflow.ExecuteActivity(ctx, ipActivities.GetLocationInfo, ip).Get(ctx, &location)
+	// workflow.Sleep returns as soon as the workflow is cancelled. Runs
+	// started before this change ignored that error and then failed on the
+	// next activity instead of reporting the cancellation.
+	cancelAware := describe.getVersion(ctx, "cancel-lookup", workflow.DefaultVersion, 1) == 1
+	// Runs started before this change stopped waiting for a running
+	// activity as soon as they were cancelled.
+	if describe.getVersion(ctx, "wait-activity-cancel", workflow.DefaultVersion, 1) == 1 {
+		ctx = workflow.WithWaitForCancellation(ctx, true)
+	}
+
+	ip := opts.IP
+	if ip == "" {
+		err = workflow.ExecuteActivity(ctx, ipActivities.GetIP).Get(ctx, &ip)
+		if err != nil {
+			if cancelAware && ctx.Err() != nil {
+				return "", temporal.NewCanceledError(ip)
+			}
+			return "", fmt.Errorf("failed to get ip: %s", err)
+		}
+		workflow.GetLogger(ctx).Info("IP fetched", "ip", ip)
+	}
+
+	if sleep := opts.demoSleep(); sleep > 0 {
+		// Sleep to give us time to modify code while workflow is running
+		workflow.GetLogger(ctx).Info("Sleeping... (this is when you'll modify the code)", "duration", sleep)
+		if err := workflow.Sleep(ctx, sleep); err != nil && cancelAware {
+			return "", temporal.NewCanceledError(ip)
+		}
+		workflow.GetLogger(ctx).Info("Awake! Now fetching location...")
+	}
+
+	location, err := lookupLocation(ctx, ip, minFieldsFor(ctx, describe, opts))
 	if err != nil {
+		if cancelAware && ctx.Err() != nil {
+			return "", temporal.NewCanceledError(ip)
+		}
+		if isLowConfidence(err) {
+			return "", err
+		}
 		return "", fmt.Errorf("failed to get location: %s", err)
 	}
 
 	return location, nil
 }
 
-func GetAddressFromIPV2(ctx workflow.Context, name string) (Data, error) {
-	ao := workflow.ActivityOptions{
-		StartToCloseTimeout: time.Minute,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    time.Second,
-			MaximumInterval:    time.Minute,
-			BackoffCoefficient: 2,
-		},
+func GetAddressFromIPV2(ctx workflow.Context, name string, opts LookupOptions) (Data, error) {
+	if err := opts.Validate(); err != nil {
+		return Data{}, err
+	}
+	describe, err := registerDescribe(ctx)
+	if err != nil {
+		return Data{}, err
 	}
+
+	ao := ActivityConfig{HeartbeatTimeout: LookupHeartbeatTimeout}.Options()
 	var ipActivities *IPActivities
 	ctx = workflow.WithActivityOptions(ctx, ao)
+	if err := registerRetryPolicy(ctx, ao.RetryPolicy); err != nil {
+		return Data{}, err
+	}
 
 	workflow.GetLogger(ctx).Info("Version 1: Starting workflow - will fetch IP, wait, then get location")
 
+	// workflow.Sleep returns as soon as the workflow is cancelled. Runs
+	// started before this change ignored that error and then failed on the
+	// next activity instead of reporting the cancellation.
+	cancelAware := describe.getVersion(ctx, "cancel-lookup", workflow.DefaultVersion, 1) == 1
+	// Runs started before this change stopped waiting for a running
+	// activity as soon as they were cancelled.
+	if describe.getVersion(ctx, "wait-activity-cancel", workflow.DefaultVersion, 1) == 1 {
+		ctx = workflow.WithWaitForCancellation(ctx, true)
+	}
+
+	// Runs started before this change ignored opts.IP and always looked up
+	// the worker's own IP.
 	var ip string
-	err := workflow.ExecuteActivity(ctx, ipActivities.GetIP).Get(ctx, &ip)
-	if err != nil {
-		return Data{}, fmt.Errorf("failed to get ip: %s", err)
+	if describe.getVersion(ctx, "v2-input-ip", workflow.DefaultVersion, 1) == 1 {
+		ip = opts.IP
+	}
+	if ip == "" {
+		err = workflow.ExecuteActivity(ctx, ipActivities.GetIP).Get(ctx, &ip)
+		if err != nil {
+			if cancelAware && ctx.Err() != nil {
+				return Data{}, temporal.NewCanceledError(Data{})
+			}
+			return Data{}, fmt.Errorf("failed to get ip: %s", err)
+		}
+		workflow.GetLogger(ctx).Info("IP fetched", "ip", ip)
 	}
-	workflow.GetLogger(ctx).Info("IP fetched", "ip", ip)
 
 	var recordedIp string
-	err = workflow.ExecuteActivity(ctx, ipActivities.RecordLookup, ip).Get(ctx, &recordedIp)
+	// The run ID keys the record, so activity retries don't duplicate it.
+	runID := workflow.GetInfo(ctx).WorkflowExecution.RunID
+	err = workflow.ExecuteActivity(ctx, ipActivities.RecordLookup, ip, runID).Get(ctx, &recordedIp)
 	if err != nil {
+		if cancelAware && ctx.Err() != nil {
+			return Data{}, temporal.NewCanceledError(Data{Result: ip})
+		}
 		return Data{}, fmt.Errorf("failed to record lookup: %s", err)
 	}
 
-	// Sleep for 45 seconds to give us time to modify code while workflow is running
-	workflow.GetLogger(ctx).Info("Sleeping for 5 seconds... (this is when you'll modify the code)")
-	workflow.Sleep(ctx, 30*time.Second)
-	workflow.GetLogger(ctx).Info("Awake! Now fetching location...")
+	// Runs started before this change ignored DemoSleep and always slept
+	// for 30 seconds. Newer runs keep 30 seconds as the default.
+	sleep := 30 * time.Second
+	if describe.getVersion(ctx, "demo-sleep", workflow.DefaultVersion, 1) == 1 && opts.DemoSleep != 0 {
+		sleep = opts.demoSleep()
+	}
+	if sleep > 0 {
+		// Sleep to give us time to modify code while workflow is running
+		workflow.GetLogger(ctx).Info("Sleeping... (this is when you'll modify the code)", "duration", sleep)
+		if err := workflow.Sleep(ctx, sleep); err != nil && cancelAware {
+			return Data{}, temporal.NewCanceledError(Data{Result: ip})
+		}
+		workflow.GetLogger(ctx).Info("Awake! Now fetching location...")
+	}
 
-	var location string
-	err = workflow.ExecuteActivity(ctx, ipActivities.GetLocationInfo, ip).Get(ctx, &location)
+	location, err := lookupLocation(ctx, ip, minFieldsFor(ctx, describe, opts))
 	if err != nil {
+		if cancelAware && ctx.Err() != nil {
+			return Data{}, temporal.NewCanceledError(Data{Result: ip})
+		}
+		if isLowConfidence(err) {
+			return Data{}, err
+		}
 		return Data{}, fmt.Errorf("failed to get location: %s", err)
 	}
 
+	// Runs started before this change always failed on a timezone error.
+	optionalZone := describe.getVersion(ctx, "optional-timezone", workflow.DefaultVersion, 1) == 1 && !opts.RequireTimezone
+
 	var zone string
 	err = workflow.ExecuteActivity(ctx, ipActivities.GetTimeZone, ip).Get(ctx, &zone)
 	if err != nil {
-		return Data{}, fmt.Errorf("failed to get timezone: %s", err)
+		if cancelAware && ctx.Err() != nil {
+			return Data{}, temporal.NewCanceledError(Data{Result: ip, Location: location})
+		}
+		if !optionalZone {
+			return Data{}, fmt.Errorf("failed to get timezone: %s", err)
+		}
+		workflow.GetLogger(ctx).Warn("Timezone lookup failed, returning location without it", "ip", ip, "error", err)
+		zone = ""
 	}
 
-	return Data{
+	result := Data{
 		Result:   ip,
 		Location: location,
 		Zone:     zone,
-	}, nil
+	}
+
+	if describe.getVersion(ctx, "record-result", workflow.DefaultVersion, 1) == 1 {
+		err = workflow.ExecuteActivity(ctx, ipActivities.RecordResult, ip, result).Get(ctx, nil)
+		if err != nil {
+			// A missed cache entry only costs a later lookup.
+			workflow.GetLogger(ctx).Warn("Failed to record result", "ip", ip, "error", err)
+		}
+	}
+
+	info := workflow.GetInfo(ctx)
+	finished := workflow.Now(ctx)
+	record := AuditRecord{
+		WorkflowID: info.WorkflowExecution.ID,
+		RunID:      info.WorkflowExecution.RunID,
+		Attempt:    info.Attempt,
+		IP:         ip,
+		Location:   location,
+		Timezone:   zone,
+		StartedAt:  info.WorkflowStartTime,
+		FinishedAt: finished,
+		DurationMs: finished.Sub(info.WorkflowStartTime).Milliseconds(),
+	}
+	workflow.GetLogger(ctx).Info("lookup complete",
+		"workflowID", record.WorkflowID,
+		"runID", record.RunID,
+		"attempt", record.Attempt,
+		"ip", record.IP,
+		"location", record.Location,
+		"timezone", record.Timezone,
+		"durationMs", record.DurationMs,
+	)
+	if describe.getVersion(ctx, "audit-record", workflow.DefaultVersion, 1) == 1 {
+		err = workflow.ExecuteActivity(ctx, ipActivities.EmitAudit, record).Get(ctx, nil)
+		if err != nil {
+			workflow.GetLogger(ctx).Warn("Failed to emit audit record", "error", err)
+		}
+	}
+
+	if opts.PublishSubject != "" {
+		err = workflow.ExecuteActivity(ctx, ipActivities.PublishResult, opts.PublishSubject, result).Get(ctx, nil)
+		if err != nil {
+			// The lookup itself succeeded; don't throw it away.
+			workflow.GetLogger(ctx).Warn("Failed to publish result", "subject", opts.PublishSubject, "error", err)
+		}
+	}
+
+	// Starters may set CallbackURL before every worker runs this code; the
+	// version keeps runs that began on an older worker replaying without it.
+	if opts.CallbackURL != "" && describe.getVersion(ctx, "callback-webhook", workflow.DefaultVersion, 1) == 1 {
+		err = workflow.ExecuteActivity(ctx, ipActivities.NotifyWebhook, opts.CallbackURL, result).Get(ctx, nil)
+		if err != nil {
+			// Retried by the activity's policy; past that, the result stands.
+			workflow.GetLogger(ctx).Warn("Failed to notify callback", "url", opts.CallbackURL, "error", err)
+		}
+	}
 
+	return result, nil
 }
 
 type Data struct {
@@ -0,0 +1,405 @@
+package iplocate
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestGetAddressFromIP_UsesInputIP(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").
+		Return("City: Ashburn, Region: Virginia, Country: United States", nil)
+
+	start := env.Now()
+	env.ExecuteWorkflow(GetAddressFromIP, "", LookupOptions{IP: "8.8.8.8", DemoSleep: NoDemoSleep})
+
+	if !env.IsWorkflowCompleted() {
+		t.Fatal("workflow did not complete")
+	}
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	var location string
+	if err := env.GetWorkflowResult(&location); err != nil {
+		t.Fatal(err)
+	}
+	if location != "City: Ashburn, Region: Virginia, Country: United States" {
+		t.Errorf("location = %q", location)
+	}
+	if slept := env.Now().Sub(start); slept >= DefaultDemoSleep {
+		t.Errorf("workflow slept %v with NoDemoSleep", slept)
+	}
+	env.AssertNotCalled(t, "GetIP", mock.Anything)
+}
+
+func TestGetAddressFromIPV2_UsesInputIP(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.OnActivity("RecordLookup", mock.Anything, "8.8.8.8", mock.Anything).Return("1-8.8.8.8", nil)
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("City: Ashburn", nil)
+	env.OnActivity("GetTimeZone", mock.Anything, "8.8.8.8").Return("America/New_York", nil)
+
+	env.ExecuteWorkflow(GetAddressFromIPV2, "", LookupOptions{IP: "8.8.8.8", DemoSleep: NoDemoSleep})
+
+	var got Data
+	if err := env.GetWorkflowResult(&got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Data{Result: "8.8.8.8", Location: "City: Ashburn", Zone: "America/New_York"}); got != want {
+		t.Errorf("result = %+v, want %+v", got, want)
+	}
+	env.AssertNotCalled(t, "GetIP", mock.Anything)
+}
+
+func TestGetAddressFromIP_DefaultsToDemoSleep(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.OnActivity("GetIP", mock.Anything).Return("8.8.8.8", nil)
+	env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("somewhere", nil)
+
+	start := env.Now()
+	env.ExecuteWorkflow(GetAddressFromIP, "", LookupOptions{})
+
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	if slept := env.Now().Sub(start); slept < DefaultDemoSleep {
+		t.Errorf("workflow slept %v, want at least %v", slept, DefaultDemoSleep)
+	}
+}
+
+func TestGetAddressFromIP_ReservedRangeIsNotRetried(t *testing.T) {
+	const url = "http://ip-api.com/json/10.0.0.1"
+	getter := &stubGetter{bodies: map[string]string{
+		url: `{"status":"fail","message":"reserved range","query":"10.0.0.1"}`,
+	}}
+
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{HTTPClient: getter})
+
+	env.ExecuteWorkflow(GetAddressFromIP, "", LookupOptions{IP: "10.0.0.1", DemoSleep: NoDemoSleep})
+
+	if err := env.GetWorkflowError(); err == nil {
+		t.Fatal("expected workflow to fail for a reserved range IP")
+	}
+	if n := getter.count(url); n != 1 {
+		t.Errorf("location lookup attempted %d times, want 1", n)
+	}
+}
+
+func TestLookupOptions_Validate(t *testing.T) {
+	valid := []LookupOptions{
+		{},
+		{IP: "8.8.8.8", DemoSleep: NoDemoSleep},
+		{IP: "2001:4860:4860::8888", PublishSubject: "lookups.done"},
+		{CallbackURL: "https://example.com/hooks/lookup"},
+	}
+	for _, o := range valid {
+		if err := o.Validate(); err != nil {
+			t.Errorf("%+v: unexpected error %v", o, err)
+		}
+	}
+
+	invalid := map[string]LookupOptions{
+		"garbage ip":         {IP: "not-an-ip"},
+		"cidr instead of ip": {IP: "8.8.8.0/24"},
+		"subject with space": {PublishSubject: "lookups done"},
+		"relative callback":  {CallbackURL: "/hooks/lookup"},
+		"non-http callback":  {CallbackURL: "ftp://example.com/"},
+	}
+	for name, o := range invalid {
+		err := o.Validate()
+		var appErr *temporal.ApplicationError
+		if !errors.As(err, &appErr) || appErr.Type() != ErrInvalidInput || !appErr.NonRetryable() {
+			t.Errorf("%s: err = %v, want non-retryable %s", name, err, ErrInvalidInput)
+		}
+	}
+}
+
+func TestGetAddressFromIP_RejectsInvalidInput(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.ExecuteWorkflow(GetAddressFromIP, "", LookupOptions{IP: "not-an-ip"})
+
+	var appErr *temporal.ApplicationError
+	if err := env.GetWorkflowError(); !errors.As(err, &appErr) || appErr.Type() != ErrInvalidInput {
+		t.Fatalf("err = %v, want %s", err, ErrInvalidInput)
+	}
+}
+
+func TestGetAddressFromIPV2_TimezoneFailure(t *testing.T) {
+	for _, require := range []bool{false, true} {
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestWorkflowEnvironment()
+		env.RegisterActivity(&IPActivities{})
+
+		env.OnActivity("GetIP", mock.Anything).Return("8.8.8.8", nil)
+		env.OnActivity("RecordLookup", mock.Anything, "8.8.8.8", mock.Anything).Return("1-8.8.8.8", nil)
+		env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("City: Ashburn", nil)
+		env.OnActivity("GetTimeZone", mock.Anything, "8.8.8.8").
+			Return("", temporal.NewNonRetryableApplicationError("provider down", "Unavailable", nil))
+
+		env.ExecuteWorkflow(GetAddressFromIPV2, "", LookupOptions{RequireTimezone: require})
+
+		err := env.GetWorkflowError()
+		if require {
+			if err == nil {
+				t.Error("RequireTimezone: expected the workflow to fail")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("workflow failed: %v", err)
+		}
+		var got Data
+		if err := env.GetWorkflowResult(&got); err != nil {
+			t.Fatal(err)
+		}
+		if want := (Data{Result: "8.8.8.8", Location: "City: Ashburn"}); got != want {
+			t.Errorf("result = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestGetAddressFromIPV2_DemoSleep(t *testing.T) {
+	for _, tc := range []struct {
+		sleep time.Duration
+		want  time.Duration
+	}{
+		{0, 30 * time.Second},
+		{NoDemoSleep, 0},
+		{5 * time.Second, 5 * time.Second},
+	} {
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestWorkflowEnvironment()
+		env.RegisterActivity(&IPActivities{})
+
+		env.OnActivity("GetIP", mock.Anything).Return("8.8.8.8", nil)
+		env.OnActivity("RecordLookup", mock.Anything, "8.8.8.8", mock.Anything).Return("1-8.8.8.8", nil)
+		env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("City: Ashburn", nil)
+		env.OnActivity("GetTimeZone", mock.Anything, "8.8.8.8").Return("America/New_York", nil)
+
+		start := env.Now()
+		env.ExecuteWorkflow(GetAddressFromIPV2, "", LookupOptions{DemoSleep: tc.sleep})
+		if err := env.GetWorkflowError(); err != nil {
+			t.Fatalf("DemoSleep %s: %v", tc.sleep, err)
+		}
+		if elapsed := env.Now().Sub(start); elapsed != tc.want {
+			t.Errorf("DemoSleep %s: slept %s, want %s", tc.sleep, elapsed, tc.want)
+		}
+	}
+}
+
+func TestGetAddressFromIPV2_RecordsResult(t *testing.T) {
+	for _, fail := range []bool{false, true} {
+		var suite testsuite.WorkflowTestSuite
+		env := suite.NewTestWorkflowEnvironment()
+		a := &IPActivities{}
+		env.RegisterActivity(a)
+
+		env.OnActivity("GetIP", mock.Anything).Return("8.8.8.8", nil)
+		env.OnActivity("RecordLookup", mock.Anything, "8.8.8.8", mock.Anything).Return("1-8.8.8.8", nil)
+		if fail {
+			env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").
+				Return("", temporal.NewNonRetryableApplicationError("provider down", "Unavailable", nil))
+		} else {
+			env.OnActivity("GetLocationInfo", mock.Anything, "8.8.8.8").Return("City: Ashburn", nil)
+		}
+		env.OnActivity("GetTimeZone", mock.Anything, "8.8.8.8").Return("America/New_York", nil)
+
+		env.ExecuteWorkflow(GetAddressFromIPV2, "", LookupOptions{DemoSleep: NoDemoSleep})
+
+		got, ok := a.cachedResult("8.8.8.8")
+		if fail {
+			if env.GetWorkflowError() == nil || ok {
+				t.Errorf("failed lookup: cached %+v, %v; want nothing", got, ok)
+			}
+			continue
+		}
+		if err := env.GetWorkflowError(); err != nil {
+			t.Fatalf("workflow failed: %v", err)
+		}
+		if want := (Data{Result: "8.8.8.8", Location: "City: Ashburn", Zone: "America/New_York"}); !ok || got != want {
+			t.Errorf("cached %+v, %v; want %+v", got, ok, want)
+		}
+	}
+}
+
+func TestGetAddressFromIP_CancelDuringSleep(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.OnActivity("GetIP", mock.Anything).Return("8.8.8.8", nil)
+
+	start := env.Now()
+	env.RegisterDelayedCallback(env.CancelWorkflow, 10*time.Second)
+	env.ExecuteWorkflow(GetAddressFromIP, "", LookupOptions{})
+
+	var canceled *temporal.CanceledError
+	if err := env.GetWorkflowError(); !errors.As(err, &canceled) {
+		t.Fatalf("err = %v, want CanceledError", err)
+	}
+	var ip string
+	if err := canceled.Details(&ip); err != nil || ip != "8.8.8.8" {
+		t.Errorf("partial result = %q, %v, want 8.8.8.8", ip, err)
+	}
+	if took := env.Now().Sub(start); took >= DefaultDemoSleep {
+		t.Errorf("workflow ran %v after cancel, want it to stop during the sleep", took)
+	}
+	env.AssertNotCalled(t, "GetLocationInfo", mock.Anything, mock.Anything)
+}
+
+func TestGetAddressFromIPV2_CancelDuringSleep(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&IPActivities{})
+
+	env.OnActivity("GetIP", mock.Anything).Return("8.8.8.8", nil)
+	env.OnActivity("RecordLookup", mock.Anything, "8.8.8.8", mock.Anything).Return("1-8.8.8.8", nil)
+
+	env.RegisterDelayedCallback(env.CancelWorkflow, 10*time.Second)
+	env.ExecuteWorkflow(GetAddressFromIPV2, "", LookupOptions{})
+
+	var canceled *temporal.CanceledError
+	if err := env.GetWorkflowError(); !errors.As(err, &canceled) {
+		t.Fatalf("err = %v, want CanceledError", err)
+	}
+	var partial Data
+	if err := canceled.Details(&partial); err != nil || partial != (Data{Result: "8.8.8.8"}) {
+		t.Errorf("partial result = %+v, %v", partial, err)
+	}
+}
+
+func TestLookupWorkflows_MinFields(t *testing.T) {
+	countryOnly := LocationDetails{IP: "41.67.0.1", Country: "Sudan", CountryCode: "SD"}
+	full := LocationDetails{IP: "41.67.0.1", City: "Khartoum", Region: "Khartoum", Country: "Sudan", CountryCode: "SD"}
+	const wantLocation = "City: Khartoum, Region: Khartoum, Country: Sudan"
+
+	workflows := map[string]struct {
+		wf       any
+		location func(*testsuite.TestWorkflowEnvironment) (string, error)
+	}{
+		"GetAddressFromIP": {GetAddressFromIP, func(env *testsuite.TestWorkflowEnvironment) (string, error) {
+			var location string
+			err := env.GetWorkflowResult(&location)
+			return location, err
+		}},
+		"GetAddressFromIPV2": {GetAddressFromIPV2, func(env *testsuite.TestWorkflowEnvironment) (string, error) {
+			var got Data
+			err := env.GetWorkflowResult(&got)
+			return got.Location, err
+		}},
+	}
+	cases := map[string]struct {
+		secondary LocationDetails
+		wantErr   string
+	}{
+		"fallback fills the gap":  {secondary: full},
+		"fallback is partial too": {secondary: countryOnly, wantErr: ErrLowConfidence},
+	}
+	for wfName, w := range workflows {
+		for name, tc := range cases {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(&IPActivities{})
+
+			env.OnActivity("RecordLookup", mock.Anything, "41.67.0.1", mock.Anything).Return("1-41.67.0.1", nil)
+			env.OnActivity("ProviderLookup", mock.Anything, PrimaryProvider, "41.67.0.1").Return(countryOnly, nil)
+			env.OnActivity("ProviderLookup", mock.Anything, SecondaryProvider, "41.67.0.1").Return(tc.secondary, nil)
+			env.OnActivity("GetTimeZone", mock.Anything, "41.67.0.1").Return("Africa/Khartoum", nil)
+
+			env.ExecuteWorkflow(w.wf, "", LookupOptions{IP: "41.67.0.1", DemoSleep: NoDemoSleep, MinFields: []string{"City", "country"}})
+
+			err := env.GetWorkflowError()
+			if tc.wantErr != "" {
+				var appErr *temporal.ApplicationError
+				if !errors.As(err, &appErr) || appErr.Type() != tc.wantErr {
+					t.Errorf("%s, %s: err = %v, want %s", wfName, name, err, tc.wantErr)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s, %s: workflow failed: %v", wfName, name, err)
+			}
+			if location, err := w.location(env); err != nil || location != wantLocation {
+				t.Errorf("%s, %s: location = %q, %v, want %q", wfName, name, location, err, wantLocation)
+			}
+			env.AssertNotCalled(t, "GetLocationInfo", mock.Anything, mock.Anything)
+		}
+	}
+}
+
+func TestLookupOptions_ValidateMinFields(t *testing.T) {
+	if err := (LookupOptions{MinFields: []string{"City", CoordinatesField}}).Validate(); err != nil {
+		t.Errorf("known fields: %v", err)
+	}
+	var appErr *temporal.ApplicationError
+	if err := (LookupOptions{MinFields: []string{"Postcode"}}).Validate(); !errors.As(err, &appErr) || appErr.Type() != ErrInvalidInput {
+		t.Errorf("unknown field: err = %v, want %s", err, ErrInvalidInput)
+	}
+}
+
+// heartbeatDoer holds each request until the activity making it has
+// heartbeated.
+type heartbeatDoer struct {
+	body       string
+	heartbeats chan string
+}
+
+func (h *heartbeatDoer) Get(url string) (*http.Response, error) {
+	return nil, errors.New("Get called instead of Do")
+}
+
+func (h *heartbeatDoer) Do(req *http.Request) (*http.Response, error) {
+	select {
+	case <-h.heartbeats:
+	case <-time.After(5 * time.Second):
+		return nil, errors.New("no heartbeat while the request was in flight")
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(h.body))}, nil
+}
+
+func TestGetAddressFromIP_HeartbeatsDuringRequest(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	doer := &heartbeatDoer{
+		body:       `{"status":"success","city":"Mountain View","regionName":"California","country":"United States"}`,
+		heartbeats: make(chan string, 100),
+	}
+	env.RegisterActivity(&IPActivities{HTTPClient: doer})
+	// Activities only heartbeat when scheduled with a HeartbeatTimeout, and
+	// a heartbeat is what delivers a workflow's cancellation to them.
+	env.SetOnActivityHeartbeatListener(func(info *activity.Info, _ converter.EncodedValues) {
+		doer.heartbeats <- info.ActivityType.Name
+	})
+
+	env.ExecuteWorkflow(GetAddressFromIP, "", LookupOptions{IP: "8.8.8.8", DemoSleep: NoDemoSleep})
+	var location string
+	if err := env.GetWorkflowResult(&location); err != nil {
+		t.Fatal(err)
+	}
+	if location != "City: Mountain View, Region: California, Country: United States" {
+		t.Errorf("location = %q", location)
+	}
+}